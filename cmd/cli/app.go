@@ -11,20 +11,28 @@ import (
 	"strings"
 
 	"github.com/mxmgorin/ch8go/pkg/chip8"
+	"github.com/mxmgorin/ch8go/pkg/db"
 	"github.com/mxmgorin/ch8go/pkg/host"
 )
 
-const maxRunSteps = 5_000_000
+const (
+	maxRunSteps   = 5_000_000
+	defaultFrames = 600 // 10 seconds at 60 FPS
+)
 
 type App struct {
 	emu     *host.Emu
 	painter ASCIIPainter
 	breaks  map[uint16]bool
 	watches map[chip8.Watch]bool
+	search  host.CheatSearch
 }
 
 func newApp() App {
 	a, _ := host.NewEmu()
+	if store, err := host.UserStore(); err == nil {
+		a.Store = store
+	}
 	return App{
 		emu:     a,
 		painter: ASCIIPainter{},
@@ -70,14 +78,154 @@ func (a *App) cmdInfo() {
 	fmt.Println(string(b))
 }
 
+func (a *App) cmdRotate(args []string) {
+	if a.loaded() {
+		return
+	}
+
+	if len(args) > 1 {
+		deg, err := strconv.Atoi(args[1])
+		if err == nil {
+			err = a.emu.SetRotation(deg)
+		}
+		if err != nil {
+			fmt.Println("Usage: rotate [0|90|180|270]")
+			fmt.Println()
+			return
+		}
+	} else {
+		a.emu.Rotate()
+	}
+
+	fmt.Printf("Rotation: %d°\n\n", a.emu.Rotation())
+}
+
+func (a *App) cmdLibrary(args []string) {
+	lib := a.emu.Library
+	if lib == nil {
+		fmt.Println("No library configured; start with --library <dirs>.")
+		fmt.Println()
+		return
+	}
+
+	if len(args) > 1 {
+		if args[1] == "scan" {
+			if err := lib.Scan(); err != nil {
+				fmt.Println(err)
+			}
+		} else {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 || n > len(lib.Entries()) {
+				fmt.Println("Usage: library [scan|<n>]")
+				fmt.Println()
+				return
+			}
+			a.loadEntry(lib.Entries()[n-1])
+			return
+		}
+	}
+
+	for i, e := range lib.Entries() {
+		played := "-"
+		if !e.LastPlayed.IsZero() {
+			played = e.LastPlayed.Format("2006-01-02")
+		}
+		fmt.Printf("%4d  %-32s %-14s %-8s %-10s %s\n", i+1, e.Title, e.Platform, e.Release, played, strings.Join(e.Authors, ", "))
+	}
+	fmt.Printf("%d ROMs.\n\n", len(lib.Entries()))
+}
+
+func (a *App) loadEntry(e host.LibraryEntry) {
+	len, err := a.emu.LoadEntry(e)
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		fmt.Printf("ROM loaded (%d bytes).\n", len)
+	}
+	fmt.Println()
+}
+
+// cmdQuirks prints each quirk with the configuration layer that set it.
+func (a *App) cmdQuirks() {
+	if a.loaded() {
+		return
+	}
+
+	q := a.emu.VM.CPU.Quirks
+	for _, name := range host.QuirkNames {
+		src := a.emu.QuirkSources[name]
+		if src == "" {
+			src = "unknown"
+		}
+		fmt.Printf("  %-22s %-5t %s\n", name, *host.QuirkField(&q, name), src)
+	}
+	fmt.Println()
+}
+
+func (a *App) cmdOptions(args []string) {
+	if a.loaded() {
+		return
+	}
+
+	o := a.emu.OctoOptions()
+	data, err := json.MarshalIndent(&o, "", "  ")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	data = append(data, '\n')
+
+	if len(args) < 2 {
+		fmt.Println(string(data))
+		return
+	}
+	if err := os.WriteFile(args[1], data, 0o644); err != nil {
+		fmt.Println(err)
+	} else {
+		fmt.Printf("Options saved to %s.\n", args[1])
+	}
+	fmt.Println()
+}
+
+const maxLookupResults = 20
+
+func (a *App) cmdLookup(args []string) {
+	if len(args) < 2 {
+		fmt.Println("Usage: lookup [title] [author:<a>] [year:<y>] [platform:<id>] [has:colors,keys,tickrate]")
+		fmt.Println()
+		return
+	}
+
+	q, err := db.ParseQuery(strings.Join(args[1:], " "))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	results := a.emu.MetaDB.Search(q)
+	for i, r := range results {
+		if i == maxLookupResults {
+			fmt.Printf("  ... %d more\n", len(results)-i)
+			break
+		}
+		p := r.Program
+		fmt.Printf("  %-32s %-8s %s\n", p.Title, p.Release, strings.Join(p.Authors, ", "))
+		for _, h := range r.Hashes {
+			rom := p.ROMs[h]
+			fmt.Printf("    %s  %s [%s]\n", h, rom.File, strings.Join(rom.Platforms, ", "))
+		}
+	}
+	fmt.Printf("%d programs.\n\n", len(results))
+}
+
 func (a *App) cmdLoad(args []string) {
 	if len(args) < 2 {
-		fmt.Println("Usage: load <rom>")
+		fmt.Println("Usage: load <rom|library title>")
 		return
 	}
 
-	path := args[1]
-	len, err := a.emu.ReadROM(path)
+	path := strings.Join(args[1:], " ")
+	len, err := a.emu.OpenROM(path)
 	if err != nil {
 		fmt.Println(err)
 	} else {
@@ -115,6 +263,8 @@ func (a *App) cmdStep(args []string) {
 		a.emu.VM.Step()
 		a.emu.VM.Poll() // clear pending VBlank so WaitVBlank ROMs advance
 	}
+	a.emu.ApplyCheats()
+	a.saveFlags()
 
 	if steps > 1 {
 		fmt.Printf("Executed %d steps.\n", steps)
@@ -257,6 +407,8 @@ func (a *App) cmdContinue(args []string) {
 	}
 
 	res := a.emu.VM.Run(a.breaks, watches, max)
+	a.emu.ApplyCheats()
+	a.saveFlags()
 	switch res.Reason {
 	case chip8.StopBreakpoint:
 		fmt.Printf("Hit breakpoint after %d steps.\n", res.Steps)
@@ -460,6 +612,372 @@ func (a *App) cmdUnwatch(args []string) {
 	fmt.Printf("Stopped watching %s.\n\n", w)
 }
 
+func (a *App) cmdWav(args []string) {
+	if a.loaded() {
+		return
+	}
+
+	if len(args) < 2 {
+		fmt.Println("Usage: wav <file> [frames]   e.g. wav beep.wav 600")
+		fmt.Println()
+		return
+	}
+
+	frames := defaultFrames
+	if len(args) >= 3 {
+		if n, err := strconv.Atoi(args[2]); err == nil && n > 0 {
+			frames = n
+		} else {
+			fmt.Println("Invalid number:", args[2])
+			return
+		}
+	}
+
+	rec := a.emu.StartAudioRecording()
+	a.emu.RunFrames(frames)
+	a.emu.StopAudioRecording()
+
+	if err := rec.SaveWAV(args[1]); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Printf("Wrote %s (%d frames, %d samples at %d Hz).\n\n", args[1], frames, rec.Len(), rec.SampleRate)
+}
+
+func (a *App) cmdRecord(args []string) {
+	if a.loaded() {
+		return
+	}
+
+	if len(args) < 2 {
+		fmt.Println("Usage: record <file.gif|file.png> [frames] [scale]   e.g. record demo.gif 600 4")
+		fmt.Println()
+		return
+	}
+
+	frames := defaultFrames
+	if len(args) >= 3 {
+		if n, err := strconv.Atoi(args[2]); err == nil && n > 0 {
+			frames = n
+		} else {
+			fmt.Println("Invalid number:", args[2])
+			return
+		}
+	}
+
+	scale := host.DefaultRecordScale
+	if len(args) >= 4 {
+		if n, err := strconv.Atoi(args[3]); err == nil && n > 0 {
+			scale = n
+		} else {
+			fmt.Println("Invalid scale:", args[3])
+			return
+		}
+	}
+
+	rec := a.emu.StartVideoRecording(scale)
+	a.emu.RunFrames(frames)
+	a.emu.StopVideoRecording()
+
+	if err := rec.Save(args[1]); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Printf("Wrote %s (%d frames, %d unique).\n\n", args[1], frames, rec.Len())
+}
+
+const maxSearchResults = 20
+
+func (a *App) cmdSearch(args []string) {
+	if a.loaded() {
+		return
+	}
+
+	if len(args) < 2 {
+		a.printSearchResults()
+		return
+	}
+
+	switch args[1] {
+	case "start":
+		a.search.Start(a.emu.VM)
+		fmt.Printf("Search started with %d candidates.\n\n", len(a.search.Results()))
+		return
+	case "reset":
+		a.search.Reset()
+		fmt.Println("Search reset.")
+		fmt.Println()
+		return
+	}
+
+	mode, err := host.ParseSearchMode(args[1])
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	var value byte
+	if mode == host.SearchEqual {
+		if len(args) < 3 {
+			fmt.Println("Usage: search eq <value>   e.g. search eq 3")
+			fmt.Println()
+			return
+		}
+		if value, err = host.ParseCheatValue(args[2]); err != nil {
+			fmt.Println(err)
+			return
+		}
+	} else if !a.search.Started() {
+		fmt.Println("No previous values to compare. Use 'search start' first.")
+		fmt.Println()
+		return
+	}
+
+	n := a.search.Filter(a.emu.VM, mode, value)
+	fmt.Printf("%d candidates left.\n", n)
+	if n <= maxSearchResults {
+		a.printSearchResults()
+	} else {
+		fmt.Println()
+	}
+}
+
+func (a *App) printSearchResults() {
+	if !a.search.Started() {
+		fmt.Println("No search. Use 'search start' or 'search eq <value>'.")
+		fmt.Println()
+		return
+	}
+
+	results := a.search.Results()
+	for i, r := range results {
+		if i == maxSearchResults {
+			fmt.Printf("  ... %d more\n", len(results)-i)
+			break
+		}
+		fmt.Printf("  %-6s = %02X (%d)\n", r.Target, r.Value, r.Value)
+	}
+	fmt.Println()
+}
+
+func (a *App) cmdFreeze(args []string) {
+	if a.loaded() {
+		return
+	}
+
+	if len(args) < 2 {
+		if len(a.emu.Cheats) == 0 {
+			fmt.Println("No frozen values.")
+		}
+		for _, c := range a.emu.Cheats {
+			fmt.Printf("  %-6s = %02X  %s\n", c.Target, c.Value, c.Description)
+		}
+		fmt.Println()
+		return
+	}
+
+	if len(args) < 3 {
+		fmt.Println("Usage: freeze <addr>|v<x> <value> [description]   e.g. freeze 0x3F0 9 lives")
+		fmt.Println()
+		return
+	}
+
+	t, val, ok := parseTargetValue(args[1], args[2])
+	if !ok {
+		return
+	}
+
+	a.emu.Freeze(t, val, strings.Join(args[3:], " "))
+	fmt.Printf("Froze %s at %02X.\n\n", t, val)
+}
+
+func (a *App) cmdUnfreeze(args []string) {
+	if len(args) < 2 {
+		a.emu.Cheats = nil
+		fmt.Println("All frozen values released.")
+		fmt.Println()
+		return
+	}
+
+	t, err := host.ParseCheatTarget(args[1])
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	if a.emu.Unfreeze(t) {
+		fmt.Printf("Released %s.\n\n", t)
+	} else {
+		fmt.Printf("%s is not frozen.\n\n", t)
+	}
+}
+
+func (a *App) cmdPoke(args []string) {
+	if a.loaded() {
+		return
+	}
+
+	if len(args) < 3 {
+		fmt.Println("Usage: poke <addr>|v<x> <value>   e.g. poke v3 0x10")
+		fmt.Println()
+		return
+	}
+
+	t, val, ok := parseTargetValue(args[1], args[2])
+	if !ok {
+		return
+	}
+
+	a.emu.Poke(t, val)
+	fmt.Printf("Wrote %02X to %s.\n\n", val, t)
+}
+
+func (a *App) cmdCheats(args []string) {
+	if a.loaded() {
+		return
+	}
+
+	if len(args) < 2 {
+		a.printCheats()
+		return
+	}
+
+	switch args[1] {
+	case "on", "off":
+		if a.emu.CheatsOn() != (args[1] == "on") {
+			a.emu.ToggleCheats()
+		}
+		fmt.Printf("Cheats %s.\n\n", args[1])
+		return
+	case "load":
+		if err := a.emu.LoadCheats(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		a.printCheats()
+		return
+	case "save":
+		if err := a.emu.SaveCheats(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("Saved %d cheats.\n\n", len(a.emu.Cheats))
+		return
+	}
+
+	n, err := strconv.Atoi(args[1])
+	if err != nil || n < 1 || n > len(a.emu.Cheats) {
+		fmt.Println("Usage: cheats [on|off|load|save|<n>]   e.g. cheats 2 toggles the second cheat")
+		fmt.Println()
+		return
+	}
+
+	c := &a.emu.Cheats[n-1]
+	c.Enabled = !c.Enabled
+	a.printCheats()
+}
+
+func (a *App) printCheats() {
+	if len(a.emu.Cheats) == 0 {
+		fmt.Println("No cheats.")
+	}
+	if !a.emu.CheatsOn() {
+		fmt.Println("Cheats are off.")
+	}
+	for i, c := range a.emu.Cheats {
+		state := "on"
+		if !c.Enabled {
+			state = "off"
+		}
+		fmt.Printf("  %2d %-3s %-6s = %02X %-6s %s\n", i+1, state, c.Target, c.Value, c.Cond, c.Description)
+	}
+	fmt.Println()
+}
+
+func (a *App) cmdOverride(args []string) {
+	if a.loaded() {
+		return
+	}
+
+	if len(args) > 1 {
+		var o host.ROMOverride
+		switch {
+		case args[1] == "save":
+			o = a.emu.LiveOverride()
+		case args[1] == "clear":
+		case args[1] == "tickrate" && len(args) > 2:
+			tr, err := strconv.Atoi(args[2])
+			if err != nil || tr <= 0 {
+				fmt.Printf("Invalid tickrate %q.\n\n", args[2])
+				return
+			}
+			a.emu.VM.SetTickrate(tr)
+			o = a.emu.LiveOverride()
+		case args[1] == "quirks" && len(args) > 2:
+			if err := host.ParseQuirks(args[2], &a.emu.VM.CPU.Quirks); err != nil {
+				fmt.Println(err)
+				return
+			}
+			o = a.emu.LiveOverride()
+		case args[1] == "font" && len(args) > 2:
+			o = a.emu.LiveOverride()
+			o.Font = args[2]
+			if err := o.Validate(); err != nil {
+				fmt.Println(err)
+				return
+			}
+			a.emu.VM.Memory.SetFont(chip8.FontStyle(o.Font))
+		default:
+			fmt.Println("Usage: override [save|clear|tickrate <n>|quirks <spec>|font <style>]   e.g. override quirks shift=1,vblank=0")
+			fmt.Println()
+			return
+		}
+
+		if err := a.emu.SaveOverride(o); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	if a.emu.Override.Empty() {
+		fmt.Println("No override for this ROM.")
+		fmt.Println()
+		return
+	}
+
+	data, err := json.MarshalIndent(&a.emu.Override, "", "  ")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(string(data))
+	fmt.Println()
+}
+
+func parseTargetValue(target, value string) (host.CheatTarget, byte, bool) {
+	t, err := host.ParseCheatTarget(target)
+	if err != nil {
+		fmt.Println(err)
+		return t, 0, false
+	}
+
+	val, err := host.ParseCheatValue(value)
+	if err != nil {
+		fmt.Println(err)
+		return t, 0, false
+	}
+
+	return t, val, true
+}
+
+func (a *App) saveFlags() {
+	if err := a.emu.SaveFlags(); err != nil {
+		fmt.Println("Failed to save flags:", err)
+	}
+}
+
 func (a *App) loaded() bool {
 	if !a.emu.Loaded() {
 		fmt.Println("No ROM. Use 'load <file>' first.")
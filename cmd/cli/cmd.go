@@ -18,6 +18,31 @@ var cmds = map[string]cmdFunc{
 		return nil
 	},
 
+	"library": func(app *App, args []string) error {
+		app.cmdLibrary(args)
+		return nil
+	},
+
+	"rotate": func(app *App, args []string) error {
+		app.cmdRotate(args)
+		return nil
+	},
+
+	"quirks": func(app *App, _args []string) error {
+		app.cmdQuirks()
+		return nil
+	},
+
+	"options": func(app *App, args []string) error {
+		app.cmdOptions(args)
+		return nil
+	},
+
+	"lookup": func(app *App, args []string) error {
+		app.cmdLookup(args)
+		return nil
+	},
+
 	"step": func(app *App, args []string) error {
 		app.cmdStep(args)
 		return nil
@@ -108,6 +133,46 @@ var cmds = map[string]cmdFunc{
 		return nil
 	},
 
+	"wav": func(app *App, args []string) error {
+		app.cmdWav(args)
+		return nil
+	},
+
+	"record": func(app *App, args []string) error {
+		app.cmdRecord(args)
+		return nil
+	},
+
+	"search": func(app *App, args []string) error {
+		app.cmdSearch(args)
+		return nil
+	},
+
+	"freeze": func(app *App, args []string) error {
+		app.cmdFreeze(args)
+		return nil
+	},
+
+	"unfreeze": func(app *App, args []string) error {
+		app.cmdUnfreeze(args)
+		return nil
+	},
+
+	"poke": func(app *App, args []string) error {
+		app.cmdPoke(args)
+		return nil
+	},
+
+	"cheats": func(app *App, args []string) error {
+		app.cmdCheats(args)
+		return nil
+	},
+
+	"override": func(app *App, args []string) error {
+		app.cmdOverride(args)
+		return nil
+	},
+
 	"exit": func(_ *App, _ []string) error { return io.EOF },
 	"quit": func(_ *App, _ []string) error { return io.EOF },
 }
@@ -116,13 +181,20 @@ func cmdHelp() {
 	fmt.Println(`
 Commands:
   help            Show all supported commands
-  load <file>     Load a ROM into memory
+  load <file>     Load a ROM into memory, or the library ROM with that title
+  library [arg]   List the library catalog; <n> loads entry n, scan rescans
   step <n>        Execute 1 or N instructions
   peek <n>        Disassemble 1 or N instructions starting from PC
   regs            Show registers
   dis             Disassemble the loaded ROM
   draw            Render the current display buffer in ASCII
   info            Show metadata about a ROM
+  rotate [deg]    Rotate the display by 90° or to 0, 90, 180 or 270 degrees
+  quirks          Show the quirks and which layer set each of them
+  options [file]  Show the configuration as Octo options JSON, or save it to file
+                  (e.g. game.json next to game.ch8)
+  lookup <query>  Search the database by title and author:, year:, platform:,
+                  has:colors,keys,tickrate
   mem <addr> [n]  Hex-dump n bytes of memory from addr (default 64)
   break <addr>    Set a breakpoint at addr (hex 0x300 or decimal)
   breaks          List breakpoints
@@ -134,6 +206,19 @@ Commands:
   keydown <hex>   Press a key (0-F)
   keyup <hex>     Release a key (0-F)
   keys            List currently pressed keys
+  wav <f> [n]     Run n frames (default 600) and save the audio to a WAV file
+  record <f> [n] [s]
+                  Run n frames and save them as an animated GIF or APNG (.png) at scale s
+  search [mode]   Find values in memory and V registers: start, eq <v>, changed,
+                  unchanged, inc, dec, reset; no mode lists candidates
+  freeze [t] [v]  Keep an addr or register (v0-vF) at v after every frame; no args lists
+  unfreeze [t]    Release a frozen value (or all if omitted)
+  poke <t> <v>    Write v to an addr or register once
+  cheats [arg]    List cheats; on/off switches all, <n> toggles one, load/save
+                  reads or writes the cheat file of the ROM
+  override [arg]  Show the user override of the ROM; save stores the current quirks,
+                  tickrate and palette, tickrate <n>, quirks <spec> or font <style>
+                  change and store them, clear removes it
   quit            Exit`)
 	fmt.Println()
 }
@@ -3,17 +3,28 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
+
+	"github.com/mxmgorin/ch8go/pkg/host"
 )
 
 func main() {
 	fmt.Println("ch8go cli. Type 'help' for commands.")
 
-	romPath := flag.String("rom", "", "path to CHIP-8 ROM")
-	flag.Parse()
+	opts, err := host.ParseOptions(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
 
 	a := newApp()
-	if *romPath != "" {
-		a.cmdLoad([]string{"load", *romPath})
+	if err := opts.Apply(a.emu); err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+
+	if opts.ROMPath != "" {
+		a.cmdLoad([]string{"load", opts.ROMPath})
 	}
 
 	a.run()
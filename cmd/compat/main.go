@@ -0,0 +1,275 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+	"github.com/mxmgorin/ch8go/pkg/host"
+)
+
+const (
+	exitOK    = 0
+	exitFail  = 1 // at least one ROM faulted or could not be checked
+	exitError = 2 // invalid arguments or unreadable ROM directories
+)
+
+var defaultDirs = []string{
+	"testdata/roms/chip8archive",
+	"testdata/roms/gamepack-chip8",
+	"testdata/roms/gamepack-schip",
+}
+
+type config struct {
+	frames int
+	outDir string
+}
+
+// entry is one row of the compatibility table.
+type entry struct {
+	title     string
+	path      string
+	tickrate  int
+	thumbnail string // relative to outDir; empty without a thumbnail
+	result    host.CompatResult
+	err       error // why the ROM could not be checked
+}
+
+func (e *entry) status() string {
+	if e.err != nil {
+		return "error"
+	}
+	return e.result.Status.String()
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("ch8go-compat", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: ch8go-compat [flags] [dir...]")
+		fs.PrintDefaults()
+	}
+
+	conf := config{}
+	fs.IntVar(&conf.frames, "frames", 600, "frames to run each ROM")
+	fs.StringVar(&conf.outDir, "out", "compat", "directory for the report and thumbnails")
+
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+
+	dirs := fs.Args()
+	if len(dirs) == 0 {
+		dirs = defaultDirs
+	}
+
+	// Per-ROM load messages would drown the summary.
+	slog.SetLogLoggerLevel(slog.LevelWarn)
+
+	paths, err := findROMs(dirs)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	if err := os.MkdirAll(filepath.Join(conf.outDir, "thumbs"), 0755); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	entries := make([]entry, 0, len(paths))
+	status := exitOK
+
+	for _, path := range paths {
+		e := check(path, conf)
+		if e.err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", path, e.err)
+		}
+
+		fmt.Fprintf(stdout, "%-16s %s\n", e.status(), path)
+		if e.err != nil || e.result.Status == host.CompatFaulted {
+			status = exitFail
+		}
+		entries = append(entries, e)
+	}
+
+	slices.SortStableFunc(entries, func(a, b entry) int {
+		return strings.Compare(strings.ToLower(a.title), strings.ToLower(b.title))
+	})
+
+	for name, write := range map[string]func(io.Writer, []entry){
+		"report.md":   writeMarkdown,
+		"report.html": writeHTML,
+	} {
+		path := filepath.Join(conf.outDir, name)
+		f, err := os.Create(path)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		write(f, entries)
+		if err := f.Close(); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+	}
+
+	fmt.Fprintf(stdout, "%s\n", summary(entries))
+	fmt.Fprintf(stdout, "report: %s\n", filepath.Join(conf.outDir, "report.md"))
+
+	return status
+}
+
+// findROMs lists ROM files under dirs: known CHIP-8 extensions plus the
+// extensionless binaries of the game packs.
+func findROMs(dirs []string) ([]string, error) {
+	var paths []string
+
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			ext := strings.ToLower(filepath.Ext(path))
+			if _, ok := chip8.PlatformByExt[ext]; ok || ext == "" {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return paths, nil
+}
+
+// check runs the ROM at path and writes its thumbnail. Failures are
+// recorded in the entry so that the sweep goes on.
+func check(path string, conf config) entry {
+	e := entry{
+		title: filepath.Base(path),
+		path:  path,
+	}
+
+	emu, err := host.NewEmu()
+	if err != nil {
+		e.err = err
+		return e
+	}
+
+	if _, err := emu.ReadROM(path); err != nil {
+		e.err = err
+		return e
+	}
+	emu.VM.CPU.Seed(1)
+
+	e.tickrate = emu.VM.Tickrate()
+	if program := emu.MetaDB.Program(emu.SourceHash); program != nil {
+		e.title = program.Title
+	}
+
+	e.result, err = emu.CheckCompat(conf.frames)
+	if err != nil {
+		e.err = fmt.Errorf("thumbnail: %w", err)
+		return e
+	}
+
+	thumbnail := filepath.Join("thumbs", emu.ROMHash+".png")
+	if err := os.WriteFile(filepath.Join(conf.outDir, thumbnail), e.result.Thumbnail, 0644); err != nil {
+		e.err = err
+		return e
+	}
+	e.thumbnail = thumbnail
+
+	return e
+}
+
+func summary(entries []entry) string {
+	counts := map[string]int{}
+	for _, e := range entries {
+		counts[e.status()]++
+	}
+
+	parts := []string{fmt.Sprintf("%d ROMs", len(entries))}
+	for s := host.CompatRan; s <= host.CompatFaulted; s++ {
+		if counts[s.String()] > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %d", s, counts[s.String()]))
+		}
+	}
+	if counts["error"] > 0 {
+		parts = append(parts, fmt.Sprintf("error: %d", counts["error"]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func details(e *entry) string {
+	if e.err != nil {
+		return e.err.Error()
+	}
+
+	r := e.result
+	switch r.Status {
+	case host.CompatFaulted:
+		return fmt.Sprintf("frame %d: %s", r.Frames, r.Fault)
+	case host.CompatUnknownOps:
+		ops := make([]string, len(r.UnknownOps))
+		for i, op := range r.UnknownOps {
+			ops[i] = fmt.Sprintf("%04X", op)
+		}
+		return strings.Join(ops, " ")
+	}
+	return ""
+}
+
+func writeMarkdown(w io.Writer, entries []entry) {
+	fmt.Fprintf(w, "# ROM Compatibility\n\n%s\n\n", summary(entries))
+	fmt.Fprintln(w, "| Title | File | Status | Tickrate | Details | Screen |")
+	fmt.Fprintln(w, "| ----- | ---- | ------ | -------- | ------- | ------ |")
+
+	for _, e := range entries {
+		screen := ""
+		if e.thumbnail != "" {
+			screen = fmt.Sprintf("![](%s)", filepath.ToSlash(e.thumbnail))
+		}
+		fmt.Fprintf(w, "| %s | `%s` | %s | %d | %s | %s |\n",
+			strings.ReplaceAll(e.title, "|", "\\|"), e.path, e.status(), e.tickrate,
+			strings.ReplaceAll(details(&e), "|", "\\|"), screen)
+	}
+}
+
+func writeHTML(w io.Writer, entries []entry) {
+	fmt.Fprintln(w, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>ROM Compatibility</title>")
+	fmt.Fprintln(w, "<style>body{font-family:sans-serif}td{padding:4px 8px}img{image-rendering:pixelated}.ran{color:green}</style>\n</head>\n<body>")
+	fmt.Fprintf(w, "<h1>ROM Compatibility</h1>\n<p>%s</p>\n<table>\n", html.EscapeString(summary(entries)))
+	fmt.Fprintln(w, "<tr><th>Title</th><th>File</th><th>Status</th><th>Tickrate</th><th>Details</th><th>Screen</th></tr>")
+
+	for _, e := range entries {
+		class := ""
+		if e.err == nil && e.result.Status == host.CompatRan {
+			class = "ran"
+		}
+		screen := ""
+		if e.thumbnail != "" {
+			screen = fmt.Sprintf("<img src=%q width=\"256\">", filepath.ToSlash(e.thumbnail))
+		}
+		fmt.Fprintf(w, "<tr><td>%s</td><td><code>%s</code></td><td class=%q>%s</td><td>%d</td><td>%s</td><td>%s</td></tr>\n",
+			html.EscapeString(e.title), html.EscapeString(e.path), class, e.status(), e.tickrate,
+			html.EscapeString(details(&e)), screen)
+	}
+
+	fmt.Fprintln(w, "</table>\n</body>\n</html>")
+}
@@ -1,44 +1,143 @@
 package main
 
 import (
+	"log/slog"
+
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/mxmgorin/ch8go/pkg/host"
 )
 
 type App struct {
 	*host.Emu
-	scale int
+	scale  int
+	filter ebiten.Filter
+	audio  *Audio
 }
 
-func newApp(scale int) (*App, error) {
+func newApp(opts host.Options) (*App, error) {
 	base, err := host.NewEmu()
 	if err != nil {
 		return nil, err
 	}
-	size := base.VM.Display.Size()
+	useUserStore(base)
+	if err := opts.Apply(base); err != nil {
+		return nil, err
+	}
 
-	ebiten.SetWindowSize(size.Width*scale, size.Height*scale)
+	size := base.VM.Display.Size()
+	ebiten.SetWindowSize(size.Width*opts.Scale, size.Height*opts.Scale)
 	ebiten.SetWindowTitle("ch8go ebiten")
 
+	filter := ebiten.FilterNearest
+	if opts.Filter == host.FilterLinear {
+		filter = ebiten.FilterLinear
+	}
+
 	return &App{
-		Emu:   base,
-		scale: scale,
+		Emu:    base,
+		scale:  opts.Scale,
+		filter: filter,
 	}, nil
 }
 
+// initAudio starts an Ebiten player draining the emulator's PCM stream.
+func (a *App) initAudio(opts host.Options) error {
+	stream := a.EnableAudio(host.DefaultSampleRate)
+	opts.ApplyAudio(stream)
+
+	audio, err := newAudio(stream)
+	if err != nil {
+		return err
+	}
+	a.audio = audio
+
+	return nil
+}
+
+// useUserStore enables per-ROM cheat files in the user configuration directory.
+func useUserStore(emu *host.Emu) {
+	store, err := host.UserStore()
+	if err != nil {
+		slog.Error("No user config directory; cheats are not loaded", "err", err)
+		return
+	}
+	emu.Store = store
+}
+
+func (a *App) toggleMute() {
+	if a.Audio != nil {
+		slog.Info("Audio:", "muted", a.Audio.ToggleMute())
+	}
+}
+
+func (a *App) adjustVolume(delta float32) {
+	if a.Audio != nil {
+		slog.Info("Audio:", "volume", a.Audio.AdjustVolume(delta))
+	}
+}
+
+func (a *App) toggleCheats() {
+	slog.Info("Cheats:", "on", a.ToggleCheats(), "count", len(a.Cheats))
+}
+
+// rotate turns the display and resizes the window to match.
+func (a *App) rotate() {
+	slog.Info("Rotation:", "degrees", a.Rotate())
+	a.resizeWindow()
+}
+
+func (a *App) resizeWindow() {
+	ebiten.SetWindowSize(a.FrameBuffer.Width*a.scale, a.FrameBuffer.Height*a.scale)
+}
+
+func (a *App) toggleAudioRecording() {
+	if rec := a.StopAudioRecording(); rec != nil {
+		path := host.RecordingPath(".wav")
+		if err := rec.SaveWAV(path); err != nil {
+			slog.Error("Failed to save audio recording", "err", err)
+			return
+		}
+		slog.Info("Audio recording saved:", "path", path, "duration", rec.Duration())
+		return
+	}
+
+	a.StartAudioRecording()
+	slog.Info("Audio recording started")
+}
+
+func (a *App) toggleVideoRecording() {
+	if rec := a.StopVideoRecording(); rec != nil {
+		path := host.RecordingPath(".gif")
+		if err := rec.Save(path); err != nil {
+			slog.Error("Failed to save video recording", "err", err)
+			return
+		}
+		slog.Info("Video recording saved:", "path", path, "frames", rec.Len(), "duration", rec.Duration())
+		return
+	}
+
+	a.StartVideoRecording(host.DefaultRecordScale)
+	slog.Info("Video recording started")
+}
+
 func (a *App) Draw(screen *ebiten.Image) {
 	screen.WritePixels(a.FrameBuffer.Pixels)
 }
 
+// DrawFinalScreen scales the frame to the window with the configured filter.
+func (a *App) DrawFinalScreen(screen ebiten.FinalScreen, offscreen *ebiten.Image, geoM ebiten.GeoM) {
+	screen.DrawImage(offscreen, &ebiten.DrawImageOptions{GeoM: geoM, Filter: a.filter})
+}
+
 func (a *App) Update() error {
+	handleHotkeys(a)
 	handleKeys(a)
 	a.RunFrame()
 	return nil
 }
 
 func (a *App) Layout(outsideW, outsideH int) (int, int) {
-	size := a.VM.Display.Size()
-	return size.Width, size.Height
+	return a.FrameBuffer.Width, a.FrameBuffer.Height
 }
 
 func (a *App) run() error {
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/mxmgorin/ch8go/pkg/host"
+)
+
+const audioBufferTime = 50 * time.Millisecond
+
+type Audio struct {
+	player *audio.Player
+}
+
+func newAudio(stream *host.AudioStream) (*Audio, error) {
+	ctx := audio.NewContext(stream.SampleRate)
+
+	player, err := ctx.NewPlayerF32(&pcmReader{stream: stream})
+	if err != nil {
+		return nil, err
+	}
+
+	player.SetBufferSize(audioBufferTime)
+	player.Play()
+
+	return &Audio{player: player}, nil
+}
+
+func (a *Audio) Close() error {
+	return a.player.Close()
+}
+
+// pcmReader adapts the mono host stream to Ebiten's interleaved stereo
+// little-endian float32 format.
+type pcmReader struct {
+	stream *host.AudioStream
+	buf    []float32
+}
+
+func (r *pcmReader) Read(p []byte) (int, error) {
+	const frameSize = 2 * 4 // two channels, four bytes per sample
+
+	frames := len(p) / frameSize
+	if cap(r.buf) < frames {
+		r.buf = make([]float32, frames)
+	}
+	r.buf = r.buf[:frames]
+	r.stream.Read(r.buf)
+
+	for i, v := range r.buf {
+		bits := math.Float32bits(v)
+		binary.LittleEndian.PutUint32(p[i*frameSize:], bits)
+		binary.LittleEndian.PutUint32(p[i*frameSize+4:], bits)
+	}
+
+	return frames * frameSize, nil
+}
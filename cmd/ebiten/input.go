@@ -1,38 +1,116 @@
 package main
 
 import (
+	"strings"
+
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/mxmgorin/ch8go/pkg/chip8"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/mxmgorin/ch8go/pkg/host"
 )
 
-var keymap = map[ebiten.Key]chip8.Key{
-	ebiten.Key1: chip8.Key1,
-	ebiten.Key2: chip8.Key2,
-	ebiten.Key3: chip8.Key3,
-	ebiten.Key4: chip8.KeyC,
-
-	ebiten.KeyQ: chip8.Key4,
-	ebiten.KeyW: chip8.Key5,
-	ebiten.KeyE: chip8.Key6,
-	ebiten.KeyR: chip8.KeyD,
-
-	ebiten.KeyA: chip8.Key7,
-	ebiten.KeyS: chip8.Key8,
-	ebiten.KeyD: chip8.Key9,
-	ebiten.KeyF: chip8.KeyE,
-
-	ebiten.KeyZ: chip8.KeyA,
-	ebiten.KeyX: chip8.Key0,
-	ebiten.KeyC: chip8.KeyB,
-	ebiten.KeyV: chip8.KeyF,
+// gamepadButtons maps the standard gamepad layout to logical buttons.
+var gamepadButtons = map[ebiten.StandardGamepadButton]host.GamepadButton{
+	ebiten.StandardGamepadButtonLeftTop:     host.ButtonUp,
+	ebiten.StandardGamepadButtonLeftBottom:  host.ButtonDown,
+	ebiten.StandardGamepadButtonLeftLeft:    host.ButtonLeft,
+	ebiten.StandardGamepadButtonLeftRight:   host.ButtonRight,
+	ebiten.StandardGamepadButtonRightBottom: host.ButtonA,
+	ebiten.StandardGamepadButtonRightRight:  host.ButtonB,
+}
+
+// joystickButtons maps the buttons of joysticks without a standard layout.
+var joystickButtons = map[ebiten.GamepadButton]host.GamepadButton{
+	ebiten.GamepadButton0: host.ButtonA,
+	ebiten.GamepadButton1: host.ButtonB,
 }
 
+// handleKeys passes the keys pressed and released this frame to the
+// emulator's mapper.
 func handleKeys(a *App) {
-	for k, v := range keymap {
-		if ebiten.IsKeyPressed(k) {
-			a.VM.Keypad.Press(v)
-		} else {
-			a.VM.Keypad.Release(v)
+	for _, k := range inpututil.AppendJustPressedKeys(nil) {
+		a.Input.Handle(keyName(k), true)
+	}
+	for _, k := range inpututil.AppendJustReleasedKeys(nil) {
+		a.Input.Handle(keyName(k), false)
+	}
+	handleGamepads(a)
+}
+
+// keyName returns the name of k in the keyboard layout, such as "A" for
+// the Q key on AZERTY keyboards, where the platform reports it, or else its
+// US layout name. Digit and numpad keys keep their own names, since the
+// digit row is the same everywhere but may be shifted.
+func keyName(k ebiten.Key) string {
+	name := k.String()
+	if strings.HasPrefix(name, "Digit") || strings.HasPrefix(name, "Numpad") {
+		return name
+	}
+	if n := ebiten.KeyName(k); n != "" {
+		return n
+	}
+	return name
+}
+
+// maxPlayers is how many gamepads are used.
+const maxPlayers = 2
+
+// handleGamepads passes the buttons held on gamepads to the emulator's
+// mapper, the first gamepad as player 1 and the second as player 2.
+func handleGamepads(a *App) {
+	ids := ebiten.AppendGamepadIDs(nil)
+	for player := 1; player <= maxPlayers; player++ {
+		held := map[host.GamepadButton]bool{}
+		if player <= len(ids) {
+			held = gamepadButtonsHeld(ids[player-1])
 		}
+		for _, b := range host.GamepadButtons {
+			a.Input.Handle(host.GamepadKey(player, b), held[b])
+		}
+	}
+}
+
+func gamepadButtonsHeld(id ebiten.GamepadID) map[host.GamepadButton]bool {
+	held := map[host.GamepadButton]bool{}
+	var x, y float64
+	if ebiten.IsStandardGamepadLayoutAvailable(id) {
+		for sb, b := range gamepadButtons {
+			if ebiten.IsStandardGamepadButtonPressed(id, sb) {
+				held[b] = true
+			}
+		}
+		x = ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickHorizontal)
+		y = ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickVertical)
+	} else {
+		for gb, b := range joystickButtons {
+			if ebiten.IsGamepadButtonPressed(id, gb) {
+				held[b] = true
+			}
+		}
+		if ebiten.GamepadAxisCount(id) >= 2 {
+			x, y = ebiten.GamepadAxisValue(id, 0), ebiten.GamepadAxisValue(id, 1)
+		}
+	}
+	for _, b := range host.StickButtons(x, y) {
+		held[b] = true
+	}
+	return held
+}
+
+func handleHotkeys(a *App) {
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyM):
+		a.toggleMute()
+	case inpututil.IsKeyJustPressed(ebiten.KeyMinus):
+		a.adjustVolume(-host.VolumeStep)
+	case inpututil.IsKeyJustPressed(ebiten.KeyEqual):
+		a.adjustVolume(host.VolumeStep)
+	case inpututil.IsKeyJustPressed(ebiten.KeyF6):
+		a.toggleCheats()
+	case inpututil.IsKeyJustPressed(ebiten.KeyF9):
+		a.rotate()
+	case inpututil.IsKeyJustPressed(ebiten.KeyF7):
+		a.toggleAudioRecording()
+	case inpututil.IsKeyJustPressed(ebiten.KeyF8):
+		a.toggleVideoRecording()
 	}
 }
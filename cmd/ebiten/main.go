@@ -22,14 +22,19 @@ func main() {
 		log.Fatal(err)
 	}
 
-	app, err := newApp(opts.Scale)
+	app, err := newApp(opts)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	if _, err := app.ReadROM(opts.ROMPath); err != nil {
+	if err := app.initAudio(opts); err != nil {
+		slog.Error("Failed to start audio", "err", err)
+	}
+
+	if _, err := app.OpenROM(opts.ROMPath); err != nil {
 		log.Fatal(err)
 	}
+	app.resizeWindow()
 
 	if err := app.run(); err != nil {
 		log.Fatal(err)
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+	"github.com/mxmgorin/ch8go/pkg/host"
+)
+
+// Exit statuses reported to the calling CI job.
+const (
+	exitOK       = 0
+	exitMismatch = 1 // the final frame hash differs from --expect-hash or a script step failed
+	exitError    = 2 // invalid arguments or the ROM/script could not be loaded
+)
+
+type config struct {
+	frames     int
+	platform   string
+	inputPath  string
+	pngPath    string
+	shotsDir   string
+	expectHash string
+	seed       int64
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("ch8go-headless", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	conf := config{}
+	fs.IntVar(&conf.frames, "frames", 600, "number of frames to run")
+	fs.StringVar(&conf.platform, "platform", "", "platform override: ch8, sc or xo")
+	fs.StringVar(&conf.inputPath, "input", "", "input script to replay")
+	fs.StringVar(&conf.pngPath, "png", "", "write the final frame to this PNG file")
+	fs.StringVar(&conf.shotsDir, "shots", ".", "directory for script screenshots")
+	fs.StringVar(&conf.expectHash, "expect-hash", "", "fail unless the final frame hash matches")
+	fs.Int64Var(&conf.seed, "seed", 1, "random seed for RND")
+
+	// Runs must not depend on the user config of the machine, so a config
+	// file is read only when --config names one.
+	opts, err := host.ParseOptionsWithConfig(fs, args, "")
+	if err != nil {
+		return exitError
+	}
+
+	if err := opts.ValidateROMPath(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	emu, err := host.NewEmu()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	if err := opts.Apply(emu); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	if _, err := emu.OpenROM(opts.ROMPath); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	if err := applyOverrides(emu.VM, conf, opts); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	var script *host.InputScript
+	if conf.inputPath != "" {
+		if script, err = host.LoadInputScript(conf.inputPath); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+	}
+
+	res, scriptErr := emu.RunScript(script, conf.frames)
+	fb := &emu.FrameBuffer
+	hash := fb.Hash()
+
+	fmt.Fprintf(stdout, "rom: %s\n", opts.ROMPath)
+	fmt.Fprintf(stdout, "frames: %d\n", res.Frames)
+	fmt.Fprintf(stdout, "tickrate: %d\n", emu.VM.Tickrate())
+	fmt.Fprintf(stdout, "quirks: %s\n", host.QuirksString(emu.VM.CPU.Quirks))
+	fmt.Fprintf(stdout, "hash: %s\n", hash)
+	fmt.Fprintln(stdout, chip8.RegistersString(&emu.VM.CPU))
+
+	if conf.pngPath != "" {
+		if err := fb.SavePNG(conf.pngPath); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		fmt.Fprintf(stdout, "png: %s\n", conf.pngPath)
+	}
+
+	for _, shot := range res.Screenshots {
+		path := filepath.Join(conf.shotsDir, shot.Name+".png")
+		if err := os.MkdirAll(conf.shotsDir, 0755); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		if err := os.WriteFile(path, shot.PNG, 0644); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		fmt.Fprintf(stdout, "screenshot: %s (frame %d)\n", path, shot.Frame)
+	}
+
+	if scriptErr != nil {
+		fmt.Fprintf(stdout, "status: script failed: %v\n", scriptErr)
+		return exitMismatch
+	}
+
+	if conf.expectHash != "" && conf.expectHash != hash {
+		fmt.Fprintf(stdout, "status: mismatch (want %s)\n", conf.expectHash)
+		return exitMismatch
+	}
+
+	fmt.Fprintln(stdout, "status: ok")
+	return exitOK
+}
+
+// applyOverrides forces the --platform conf. LoadROM already applied the
+// tickrate and quirk overrides, so they are applied again on top of it.
+func applyOverrides(vm *chip8.VM, conf config, opts host.Options) error {
+	if conf.platform != "" {
+		pc, ok := chip8.ConfByPlatform[chip8.Platform(conf.platform)]
+		if !ok {
+			return fmt.Errorf("unknown platform %q (use ch8, sc or xo)", conf.platform)
+		}
+		slog.Info("Platform override:", "platform", conf.platform)
+		vm.SetConf(pc)
+	}
+
+	// The emulator takes these as defaults below the database; CI runs
+	// force them.
+	if opts.Tickrate > 0 {
+		vm.SetTickrate(opts.Tickrate)
+	}
+	if err := host.ParseQuirks(opts.Quirks, &vm.CPU.Quirks); err != nil {
+		return err
+	}
+
+	vm.CPU.Seed(conf.seed)
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunIgnoresUserConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("AppData", filepath.Join(home, "AppData"))
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		t.Skip(err)
+	}
+	path := filepath.Join(dir, "ch8go", "config.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Invalid, so that reading it fails the run.
+	if err := os.WriteFile(path, []byte(`{"platform": "megachip"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rom := "../../testdata/roms/test/timendus/2-ibm-logo.ch8"
+	if got := run([]string{"--rom", rom, "--frames", "1"}, io.Discard, io.Discard); got != exitOK {
+		t.Fatalf("run = %d, want %d: the user config was read", got, exitOK)
+	}
+	if got := run([]string{"--rom", rom, "--frames", "1", "--config", path}, io.Discard, io.Discard); got != exitError {
+		t.Errorf("run with --config = %d, want %d", got, exitError)
+	}
+}
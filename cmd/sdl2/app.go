@@ -1,6 +1,7 @@
 package main
 
 import (
+	"log/slog"
 	"time"
 
 	"github.com/mxmgorin/ch8go/pkg/host"
@@ -9,11 +10,13 @@ import (
 
 type App struct {
 	*host.Emu
-	painter *Painter
+	painter  *Painter
+	audio    *Audio
+	gamepads *gamepads
 }
 
-func newApp() (*App, error) {
-	if err := sdl.Init(sdl.INIT_VIDEO); err != nil {
+func newApp(opts host.Options) (*App, error) {
+	if err := sdl.Init(sdl.INIT_VIDEO | sdl.INIT_AUDIO | sdl.INIT_GAMECONTROLLER); err != nil {
 		return nil, err
 	}
 
@@ -21,17 +24,103 @@ func newApp() (*App, error) {
 	if err != nil {
 		return nil, err
 	}
+	useUserStore(emu)
+	if err := opts.Apply(emu); err != nil {
+		return nil, err
+	}
+
+	if opts.Filter == host.FilterLinear {
+		sdl.SetHint(sdl.HINT_RENDER_SCALE_QUALITY, "linear")
+	}
 
 	size := emu.VM.Display.Size()
-	painter, err := newPainter(size.Width, size.Height, 10)
+	painter, err := newPainter(size.Width, size.Height, opts.Scale)
 	if err != nil {
 		return nil, err
 	}
 
-	return &App{Emu: emu, painter: painter}, nil
+	return &App{Emu: emu, painter: painter, gamepads: newGamepads()}, nil
+}
+
+// initAudio opens the audio device and starts draining the emulator's PCM stream.
+func (a *App) initAudio(opts host.Options) error {
+	stream := a.EnableAudio(host.DefaultSampleRate)
+	opts.ApplyAudio(stream)
+
+	audio, err := newAudio(stream)
+	if err != nil {
+		return err
+	}
+	a.audio = audio
+
+	return nil
+}
+
+// useUserStore enables per-ROM cheat files in the user configuration directory.
+func useUserStore(emu *host.Emu) {
+	store, err := host.UserStore()
+	if err != nil {
+		slog.Error("No user config directory; cheats are not loaded", "err", err)
+		return
+	}
+	emu.Store = store
+}
+
+func (a *App) toggleMute() {
+	if a.Audio != nil {
+		slog.Info("Audio:", "muted", a.Audio.ToggleMute())
+	}
+}
+
+func (a *App) adjustVolume(delta float32) {
+	if a.Audio != nil {
+		slog.Info("Audio:", "volume", a.Audio.AdjustVolume(delta))
+	}
+}
+
+func (a *App) toggleCheats() {
+	slog.Info("Cheats:", "on", a.ToggleCheats(), "count", len(a.Cheats))
+}
+
+func (a *App) rotate() {
+	slog.Info("Rotation:", "degrees", a.Rotate())
+}
+
+func (a *App) toggleAudioRecording() {
+	if rec := a.StopAudioRecording(); rec != nil {
+		path := host.RecordingPath(".wav")
+		if err := rec.SaveWAV(path); err != nil {
+			slog.Error("Failed to save audio recording", "err", err)
+			return
+		}
+		slog.Info("Audio recording saved:", "path", path, "duration", rec.Duration())
+		return
+	}
+
+	a.StartAudioRecording()
+	slog.Info("Audio recording started")
+}
+
+func (a *App) toggleVideoRecording() {
+	if rec := a.StopVideoRecording(); rec != nil {
+		path := host.RecordingPath(".gif")
+		if err := rec.Save(path); err != nil {
+			slog.Error("Failed to save video recording", "err", err)
+			return
+		}
+		slog.Info("Video recording saved:", "path", path, "frames", rec.Len(), "duration", rec.Duration())
+		return
+	}
+
+	a.StartVideoRecording(host.DefaultRecordScale)
+	slog.Info("Video recording started")
 }
 
 func (a *App) Quit() {
+	if a.audio != nil {
+		a.audio.Close()
+	}
+	a.gamepads.closeAll(a.Input)
 	a.painter.Destroy()
 	sdl.Quit()
 }
@@ -51,10 +140,16 @@ func (a *App) Run() error {
 			case *sdl.KeyboardEvent:
 				switch ev.Type {
 				case sdl.KEYDOWN:
-					handleKey(ev.Keysym.Sym, &a.VM.Keypad, true)
+					if ev.Repeat == 0 {
+						handleHotkey(ev.Keysym.Sym, a)
+					}
+					handleKey(ev.Keysym.Sym, a.Input, true)
 				case sdl.KEYUP:
-					handleKey(ev.Keysym.Sym, &a.VM.Keypad, false)
+					handleKey(ev.Keysym.Sym, a.Input, false)
 				}
+
+			default:
+				a.gamepads.handleEvent(ev, a.Input)
 			}
 		}
 
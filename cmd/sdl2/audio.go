@@ -0,0 +1,63 @@
+package main
+
+/*
+typedef unsigned char Uint8;
+void audioCallback(void *userdata, Uint8 *stream, int len);
+*/
+import "C"
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/mxmgorin/ch8go/pkg/host"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const audioBufSize = 512
+
+// stream is drained from SDL's audio thread. cgo callbacks cannot receive Go
+// pointers through userdata, so the active stream lives in a package variable.
+var stream atomic.Pointer[host.AudioStream]
+
+//export audioCallback
+func audioCallback(userdata unsafe.Pointer, buf *C.Uint8, length C.int) {
+	out := unsafe.Slice((*float32)(unsafe.Pointer(buf)), int(length)/4)
+
+	s := stream.Load()
+	if s == nil {
+		clear(out)
+		return
+	}
+
+	s.Read(out)
+}
+
+type Audio struct {
+	device sdl.AudioDeviceID
+}
+
+func newAudio(s *host.AudioStream) (*Audio, error) {
+	stream.Store(s)
+
+	spec := sdl.AudioSpec{
+		Freq:     int32(s.SampleRate),
+		Format:   sdl.AUDIO_F32SYS,
+		Channels: 1,
+		Samples:  audioBufSize,
+		Callback: sdl.AudioCallback(C.audioCallback),
+	}
+
+	device, err := sdl.OpenAudioDevice("", false, &spec, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	sdl.PauseAudioDevice(device, false)
+
+	return &Audio{device: device}, nil
+}
+
+func (a *Audio) Close() {
+	sdl.CloseAudioDevice(a.device)
+}
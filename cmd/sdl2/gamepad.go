@@ -0,0 +1,192 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/mxmgorin/ch8go/pkg/host"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// controllerButtons maps game controller buttons to logical buttons.
+var controllerButtons = map[uint8]host.GamepadButton{
+	sdl.CONTROLLER_BUTTON_DPAD_UP:    host.ButtonUp,
+	sdl.CONTROLLER_BUTTON_DPAD_DOWN:  host.ButtonDown,
+	sdl.CONTROLLER_BUTTON_DPAD_LEFT:  host.ButtonLeft,
+	sdl.CONTROLLER_BUTTON_DPAD_RIGHT: host.ButtonRight,
+	sdl.CONTROLLER_BUTTON_A:          host.ButtonA,
+	sdl.CONTROLLER_BUTTON_B:          host.ButtonB,
+}
+
+// joystickButtons maps the buttons of joysticks SDL has no controller
+// mapping for.
+var joystickButtons = map[uint8]host.GamepadButton{
+	0: host.ButtonA,
+	1: host.ButtonB,
+}
+
+var directions = []host.GamepadButton{host.ButtonUp, host.ButtonDown, host.ButtonLeft, host.ButtonRight}
+
+// gamepad is an open game controller or plain joystick.
+type gamepad struct {
+	player     int                 // 1 or 2
+	controller *sdl.GameController // nil for plain joysticks
+	joystick   *sdl.Joystick
+	x, y       float64 // left stick, -1 to 1
+	hat        uint8
+	held       map[host.GamepadButton]bool // directions of the stick and hat
+}
+
+// maxPlayers is how many gamepads are used.
+const maxPlayers = 2
+
+// gamepads passes the buttons of the first two gamepads connected to the
+// emulator's mapper.
+type gamepads struct {
+	pads map[sdl.JoystickID]*gamepad
+}
+
+func newGamepads() *gamepads {
+	return &gamepads{pads: map[sdl.JoystickID]*gamepad{}}
+}
+
+// open opens the device at index as a controller if SDL knows its
+// mapping, or else as a joystick.
+func (g *gamepads) open(index int) {
+	player := g.freePlayer()
+	if player == 0 {
+		return
+	}
+
+	pad := &gamepad{player: player, held: map[host.GamepadButton]bool{}}
+	if sdl.IsGameController(index) {
+		pad.controller = sdl.GameControllerOpen(index)
+		if pad.controller == nil {
+			slog.Error("Failed to open game controller", "err", sdl.GetError())
+			return
+		}
+		pad.joystick = pad.controller.Joystick()
+	} else {
+		pad.joystick = sdl.JoystickOpen(index)
+		if pad.joystick == nil {
+			slog.Error("Failed to open joystick", "err", sdl.GetError())
+			return
+		}
+	}
+
+	g.pads[pad.joystick.InstanceID()] = pad
+	slog.Info("Gamepad connected:", "name", pad.joystick.Name(), "player", player)
+}
+
+func (g *gamepads) freePlayer() int {
+	for player := 1; player <= maxPlayers; player++ {
+		free := true
+		for _, pad := range g.pads {
+			free = free && pad.player != player
+		}
+		if free {
+			return player
+		}
+	}
+	return 0
+}
+
+// close closes a gamepad, releasing its buttons since an unplugged
+// gamepad sends no button or axis events.
+func (g *gamepads) close(id sdl.JoystickID, input *host.InputMapper) {
+	pad, ok := g.pads[id]
+	if !ok {
+		return
+	}
+	for _, b := range host.GamepadButtons {
+		input.Handle(host.GamepadKey(pad.player, b), false)
+	}
+	if pad.controller != nil {
+		pad.controller.Close()
+	} else {
+		pad.joystick.Close()
+	}
+	delete(g.pads, id)
+	slog.Info("Gamepad disconnected:", "player", pad.player)
+}
+
+func (g *gamepads) closeAll(input *host.InputMapper) {
+	for id := range g.pads {
+		g.close(id, input)
+	}
+}
+
+// handleEvent handles gamepad events, ignoring others.
+func (g *gamepads) handleEvent(ev sdl.Event, input *host.InputMapper) {
+	switch ev := ev.(type) {
+	// Controllers are also reported as joysticks, so both are opened here.
+	case *sdl.JoyDeviceAddedEvent:
+		g.open(int(ev.Which))
+	case *sdl.JoyDeviceRemovedEvent:
+		g.close(ev.Which, input)
+
+	case *sdl.ControllerButtonEvent:
+		if pad := g.pads[ev.Which]; pad != nil {
+			g.button(pad, controllerButtons, ev.Button, ev.State == sdl.PRESSED, input)
+		}
+	case *sdl.ControllerAxisEvent:
+		if pad := g.pads[ev.Which]; pad != nil {
+			g.axis(pad, int(ev.Axis), ev.Value, input) // LEFTX and LEFTY are 0 and 1
+		}
+
+	// Controllers also send joystick events; only plain joysticks use them.
+	case *sdl.JoyButtonEvent:
+		if pad := g.pads[ev.Which]; pad != nil && pad.controller == nil {
+			g.button(pad, joystickButtons, ev.Button, ev.State == sdl.PRESSED, input)
+		}
+	case *sdl.JoyAxisEvent:
+		if pad := g.pads[ev.Which]; pad != nil && pad.controller == nil {
+			g.axis(pad, int(ev.Axis), ev.Value, input)
+		}
+	case *sdl.JoyHatEvent:
+		if pad := g.pads[ev.Which]; pad != nil && pad.controller == nil && ev.Hat == 0 {
+			pad.hat = ev.Value
+			g.directions(pad, input)
+		}
+	}
+}
+
+func (g *gamepads) button(pad *gamepad, buttons map[uint8]host.GamepadButton, button uint8, down bool, input *host.InputMapper) {
+	if b, ok := buttons[button]; ok {
+		input.Handle(host.GamepadKey(pad.player, b), down)
+	}
+}
+
+// axis updates the horizontal (0) or vertical (1) stick axis.
+func (g *gamepads) axis(pad *gamepad, axis int, value int16, input *host.InputMapper) {
+	v := float64(value) / 32767
+	switch axis {
+	case 0:
+		pad.x = v
+	case 1:
+		pad.y = v
+	default:
+		return
+	}
+	g.directions(pad, input)
+}
+
+// directions presses and releases the directions of the stick and hat
+// that changed.
+func (g *gamepads) directions(pad *gamepad, input *host.InputMapper) {
+	held := map[host.GamepadButton]bool{
+		host.ButtonUp:    pad.hat&sdl.HAT_UP != 0,
+		host.ButtonDown:  pad.hat&sdl.HAT_DOWN != 0,
+		host.ButtonLeft:  pad.hat&sdl.HAT_LEFT != 0,
+		host.ButtonRight: pad.hat&sdl.HAT_RIGHT != 0,
+	}
+	for _, b := range host.StickButtons(pad.x, pad.y) {
+		held[b] = true
+	}
+
+	for _, b := range directions {
+		if held[b] != pad.held[b] {
+			input.Handle(host.GamepadKey(pad.player, b), held[b])
+		}
+	}
+	pad.held = held
+}
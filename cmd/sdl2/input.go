@@ -1,34 +1,31 @@
 package main
 
 import (
-	"github.com/mxmgorin/ch8go/pkg/chip8"
+	"github.com/mxmgorin/ch8go/pkg/host"
 	"github.com/veandco/go-sdl2/sdl"
 )
 
-var keymap = map[sdl.Keycode]chip8.Key{
-	sdl.K_1: chip8.Key1,
-	sdl.K_2: chip8.Key2,
-	sdl.K_3: chip8.Key3,
-	sdl.K_4: chip8.KeyC,
-
-	sdl.K_q: chip8.Key4,
-	sdl.K_w: chip8.Key5,
-	sdl.K_e: chip8.Key6,
-	sdl.K_r: chip8.KeyD,
-
-	sdl.K_a: chip8.Key7,
-	sdl.K_s: chip8.Key8,
-	sdl.K_d: chip8.Key9,
-	sdl.K_f: chip8.KeyE,
-
-	sdl.K_z: chip8.KeyA,
-	sdl.K_x: chip8.Key0,
-	sdl.K_c: chip8.KeyB,
-	sdl.K_v: chip8.KeyF,
+// handleKey passes a key to the emulator's mapper under its SDL name, such
+// as "Q", "Up" or "Keypad 8".
+func handleKey(key sdl.Keycode, input *host.InputMapper, down bool) {
+	input.Handle(sdl.GetKeyName(key), down)
 }
 
-func handleKey(key sdl.Keycode, keypad *chip8.Keypad, down bool) {
-	if k, ok := keymap[key]; ok {
-		keypad.HandleKey(k, down)
+func handleHotkey(key sdl.Keycode, a *App) {
+	switch key {
+	case sdl.K_m:
+		a.toggleMute()
+	case sdl.K_MINUS:
+		a.adjustVolume(-host.VolumeStep)
+	case sdl.K_EQUALS:
+		a.adjustVolume(host.VolumeStep)
+	case sdl.K_F6:
+		a.toggleCheats()
+	case sdl.K_F9:
+		a.rotate()
+	case sdl.K_F7:
+		a.toggleAudioRecording()
+	case sdl.K_F8:
+		a.toggleVideoRecording()
 	}
 }
@@ -24,14 +24,18 @@ func main() {
 		log.Fatal(err)
 	}
 
-	app, err := newApp()
+	app, err := newApp(opts)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	defer app.Quit()
 
-	if _, err := app.ReadROM(opts.ROMPath); err != nil {
+	if err := app.initAudio(opts); err != nil {
+		slog.Error("Failed to open audio device", "err", err)
+	}
+
+	if _, err := app.OpenROM(opts.ROMPath); err != nil {
 		log.Fatal(err)
 	}
 
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"log/slog"
 	"unsafe"
 
 	"github.com/mxmgorin/ch8go/pkg/host"
@@ -12,6 +13,8 @@ type Painter struct {
 	texture  *sdl.Texture
 	renderer *sdl.Renderer
 	scale    int
+	width    int
+	height   int
 }
 
 func newPainter(width, height, scale int) (*Painter, error) {
@@ -24,7 +27,7 @@ func newPainter(width, height, scale int) (*Painter, error) {
 	if err != nil {
 		return nil, err
 	}
-	p := Painter{}
+	p := Painter{scale: scale}
 	p.window = window
 
 	renderer, err := sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
@@ -33,20 +36,48 @@ func newPainter(width, height, scale int) (*Painter, error) {
 	}
 	p.renderer = renderer
 
-	texture, err := renderer.CreateTexture(
+	if err := p.createTexture(width, height); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func (p *Painter) createTexture(width, height int) error {
+	texture, err := p.renderer.CreateTexture(
 		sdl.PIXELFORMAT_ABGR8888,
 		sdl.TEXTUREACCESS_STREAMING,
 		int32(width),
 		int32(height))
 	if err != nil {
-		return nil, err
+		return err
 	}
 	p.texture = texture
+	p.width = width
+	p.height = height
 
-	return &p, nil
+	return nil
+}
+
+// resize recreates the texture and window for a rotated frame.
+func (p *Painter) resize(width, height int) error {
+	p.texture.Destroy()
+	if err := p.createTexture(width, height); err != nil {
+		return err
+	}
+	p.window.SetSize(int32(width*p.scale), int32(height*p.scale))
+
+	return nil
 }
 
 func (p *Painter) Paint(fb *host.FrameBuffer) {
+	if fb.Width != p.width || fb.Height != p.height {
+		if err := p.resize(fb.Width, fb.Height); err != nil {
+			slog.Error("Failed to resize display", "err", err)
+			return
+		}
+	}
+
 	p.texture.Update(nil, unsafe.Pointer(&fb.Pixels[0]), fb.Pitch())
 	p.renderer.Clear()
 	p.renderer.Copy(p.texture, nil, nil)
@@ -3,17 +3,20 @@
 package main
 
 import (
+	"encoding/json"
 	"log"
 	"log/slog"
-	"path/filepath"
+	"path"
+	"strconv"
 	"syscall/js"
 
+	"github.com/mxmgorin/ch8go/pkg/db"
 	"github.com/mxmgorin/ch8go/pkg/host"
 )
 
 type App struct {
 	emu               *host.Emu
-	painter           Painter
+	painter           *Painter
 	audio             Audio
 	input             Input
 	confOverlay       ConfOverlay
@@ -21,6 +24,11 @@ type App struct {
 	runFrameFunc      js.Func
 	togglePauseIconEl js.Value
 	pauseOverlayEl    js.Value
+	cheatsInput       js.Value
+	saveFlagsInput    js.Value
+	rotationInput     js.Value
+	layoutInput       js.Value
+	romName           string
 	keyChan           chan KeyEvent
 }
 
@@ -29,6 +37,10 @@ func newApp() App {
 	if err != nil {
 		log.Fatal(err)
 	}
+	emu.Input, err = host.NewInputMapper(host.LayoutQWERTY, nil, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	displaySize := emu.VM.Display.Size()
 	painter, err := newPainter(displaySize.Width, displaySize.Height)
@@ -41,22 +53,37 @@ func newApp() App {
 	doc := js.Global().Get("document")
 	win := js.Global().Get("window")
 	keyChan := make(chan KeyEvent, 32)
+	emu.Store = newLocalStore(win)
 
 	a := App{
 		palettePicker:     newPalettePicker(doc, &emu.Palette),
 		painter:           painter,
 		audio:             newAudio(jsGlobal, &emu.VM.Audio),
-		input:             newInput(win, keyChan),
+		input:             newInput(win, emu.Input, keyChan),
 		confOverlay:       newConfOverlay(doc, emu.VM),
 		togglePauseIconEl: doc.Call("getElementById", "toggle-pause-icon"),
 		pauseOverlayEl:    doc.Call("getElementById", "pause-overlay"),
+		cheatsInput:       doc.Call("getElementById", "cheatsInput"),
+		saveFlagsInput:    doc.Call("getElementById", "saveFlagsInput"),
+		rotationInput:     doc.Call("getElementById", "rotationInput"),
+		layoutInput:       doc.Call("getElementById", "layoutInput"),
 		keyChan:           keyChan,
 		emu:               emu,
 	}
 
 	jsGlobal.Set("chip8_loadROM", js.FuncOf(a.loadROM))
+	jsGlobal.Set("chip8_searchDB", js.FuncOf(a.searchDB))
+	jsGlobal.Set("chip8_exportOptions", js.FuncOf(a.exportOptions))
 	togglePauseBtn := doc.Call("getElementById", "toggle-pause-btn")
 	togglePauseBtn.Call("addEventListener", "click", js.FuncOf(a.togglePause))
+	a.cheatsInput.Call("addEventListener", "input", js.FuncOf(a.toggleCheats))
+	doc.Call("getElementById", "saveOverrideBtn").Call("addEventListener", "click", js.FuncOf(a.saveOverride))
+	doc.Call("getElementById", "clearOverrideBtn").Call("addEventListener", "click", js.FuncOf(a.clearOverride))
+	doc.Call("getElementById", "exportOptionsBtn").Call("addEventListener", "click", js.FuncOf(a.downloadOptions))
+	a.saveFlagsInput.Set("checked", js.ValueOf(emu.PersistFlags))
+	a.saveFlagsInput.Call("addEventListener", "input", js.FuncOf(a.toggleSaveFlags))
+	a.rotationInput.Call("addEventListener", "input", js.FuncOf(a.setRotation))
+	a.layoutInput.Call("addEventListener", "input", js.FuncOf(a.setLayout))
 
 	// Animation loop (must persist function or GC will kill it)
 	a.runFrameFunc = js.FuncOf(a.runFrame)
@@ -69,19 +96,89 @@ func (a *App) loadROM(this js.Value, args []js.Value) any {
 	name := args[1].String()
 	buf := make([]byte, jsBuff.Length())
 	js.CopyBytesToGo(buf, jsBuff)
-	_, err := a.emu.LoadROM(buf, filepath.Ext(name))
+	a.romName = path.Base(name)
+	_, err := a.emu.LoadFile(buf, name, "")
 	if err != nil {
 		slog.Error("Failed to LoadROM", "err", err)
+		js.Global().Call("alert", err.Error())
 	}
 
 	a.palettePicker.setColors(&a.emu.Palette.Pixels)
 	a.confOverlay.setTickrate(a.emu.VM.Tickrate())
 	a.confOverlay.setQuirks(a.emu.VM.CPU.Quirks)
 	setROMInfo(a.emu.ROMInfo())
+	a.cheatsInput.Set("checked", js.ValueOf(a.emu.CheatsOn()))
+	a.cheatsInput.Set("disabled", js.ValueOf(len(a.emu.Cheats) == 0))
+	a.rotationInput.Set("value", js.ValueOf(strconv.Itoa(a.emu.Rotation())))
+
+	return nil
+}
+
+func (a *App) setRotation(this js.Value, args []js.Value) any {
+	deg, err := strconv.Atoi(a.rotationInput.Get("value").String())
+	if err == nil {
+		err = a.emu.SetRotation(deg)
+	}
+	if err != nil {
+		slog.Error("Failed to rotate", "err", err)
+	}
+
+	return nil
+}
+
+func (a *App) setLayout(this js.Value, args []js.Value) any {
+	if err := a.emu.Input.SetLayout(a.layoutInput.Get("value").String()); err != nil {
+		slog.Error("Failed to set keyboard layout", "err", err)
+	}
 
 	return nil
 }
 
+func (a *App) toggleCheats(this js.Value, args []js.Value) any {
+	if a.cheatsInput.Get("checked").Bool() != a.emu.CheatsOn() {
+		slog.Info("Cheats:", "on", a.emu.ToggleCheats())
+	}
+
+	return nil
+}
+
+// searchDB runs a database query such as "tetris has:colors" and returns
+// an array of {title, release, authors, hashes} objects.
+func (a *App) searchDB(this js.Value, args []js.Value) any {
+	if len(args) == 0 {
+		return js.ValueOf([]any{})
+	}
+	q, err := db.ParseQuery(args[0].String())
+	if err != nil {
+		slog.Error("Invalid query", "err", err)
+		return js.ValueOf([]any{})
+	}
+	if len(args) > 1 {
+		q.Limit = args[1].Int()
+	}
+
+	results := a.emu.MetaDB.Search(q)
+	out := make([]any, len(results))
+	for i, r := range results {
+		authors := make([]any, len(r.Program.Authors))
+		for j, au := range r.Program.Authors {
+			authors[j] = au
+		}
+		hashes := make([]any, len(r.Hashes))
+		for j, h := range r.Hashes {
+			hashes[j] = h
+		}
+		out[i] = map[string]any{
+			"title":   r.Program.Title,
+			"release": r.Program.Release,
+			"authors": authors,
+			"hashes":  hashes,
+		}
+	}
+
+	return js.ValueOf(out)
+}
+
 func (a *App) togglePause(this js.Value, args []js.Value) any {
 	a.emu.Paused = !a.emu.Paused
 
@@ -97,10 +194,16 @@ func (a *App) togglePause(this js.Value, args []js.Value) any {
 }
 
 func (a *App) handleKey(evt KeyEvent) {
+	if !evt.Screen {
+		a.emu.Input.Handle(evt.Name, evt.Pressed)
+		return
+	}
+
+	k, _ := host.LayoutKey(host.LayoutQWERTY, evt.Name) // checked by onKey
 	if evt.Pressed {
-		a.emu.VM.Keypad.Press(evt.Key)
+		a.emu.VM.Keypad.Press(k)
 	} else {
-		a.emu.VM.Keypad.Release(evt.Key)
+		a.emu.VM.Keypad.Release(k)
 	}
 }
 
@@ -113,6 +216,66 @@ func (a *App) runFrame(this js.Value, args []js.Value) any {
 	return nil
 }
 
+func (a *App) toggleSaveFlags(this js.Value, args []js.Value) any {
+	a.emu.PersistFlags = a.saveFlagsInput.Get("checked").Bool()
+	slog.Info("Save flags:", "on", a.emu.PersistFlags)
+
+	return nil
+}
+
+// saveOverride stores the settings panel edits for the loaded ROM.
+func (a *App) saveOverride(this js.Value, args []js.Value) any {
+	if err := a.emu.SaveOverride(a.emu.LiveOverride()); err != nil {
+		slog.Error("Failed to save ROM override", "err", err)
+		return nil
+	}
+	slog.Info("ROM override saved:", "key", a.emu.OverrideKey())
+
+	return nil
+}
+
+func (a *App) clearOverride(this js.Value, args []js.Value) any {
+	if err := a.emu.SaveOverride(host.ROMOverride{}); err != nil {
+		slog.Error("Failed to clear ROM override", "err", err)
+	}
+
+	return nil
+}
+
+// exportOptions returns the current configuration as Octo options JSON.
+func (a *App) exportOptions(this js.Value, args []js.Value) any {
+	o := a.emu.OctoOptions()
+	data, err := json.MarshalIndent(&o, "", "  ")
+	if err != nil {
+		slog.Error("Failed to export options", "err", err)
+		return nil
+	}
+	return string(data)
+}
+
+// downloadOptions saves the Octo options as a sidecar file for the ROM.
+func (a *App) downloadOptions(this js.Value, args []js.Value) any {
+	if !a.emu.Loaded() {
+		return nil
+	}
+	data := a.exportOptions(this, args)
+	if data == nil {
+		return nil
+	}
+
+	doc := js.Global().Get("document")
+	url := js.Global().Get("URL")
+	blob := js.Global().Get("Blob").New([]any{data}, map[string]any{"type": "application/json"})
+	href := url.Call("createObjectURL", blob)
+	link := doc.Call("createElement", "a")
+	link.Set("href", href)
+	link.Set("download", host.SidecarPath(a.romName))
+	link.Call("click")
+	url.Call("revokeObjectURL", href)
+
+	return nil
+}
+
 // Run main loop
 func (a *App) run() {
 	js.Global().Call("requestAnimationFrame", a.runFrameFunc)
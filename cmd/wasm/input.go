@@ -3,27 +3,21 @@
 package main
 
 import (
+	"strings"
 	"syscall/js"
 
-	"github.com/mxmgorin/ch8go/pkg/chip8"
+	"github.com/mxmgorin/ch8go/pkg/host"
 )
 
 type Input struct {
-	keymap  map[string]chip8.Key
+	mapper  *host.InputMapper
 	keyChan chan KeyEvent
 }
 
-func newInput(window js.Value, keyChan chan KeyEvent) Input {
+func newInput(window js.Value, mapper *host.InputMapper, keyChan chan KeyEvent) Input {
 	i := Input{
+		mapper:  mapper,
 		keyChan: keyChan,
-		keymap: map[string]chip8.Key{
-			"1": chip8.Key1, "2": chip8.Key2, "3": chip8.Key3, "4": chip8.KeyC,
-			"q": chip8.Key4, "w": chip8.Key5, "e": chip8.Key6, "r": chip8.KeyD,
-			"a": chip8.Key7, "s": chip8.Key8, "d": chip8.Key9, "f": chip8.KeyE,
-			"z": chip8.KeyA, "x": chip8.Key0, "c": chip8.KeyB, "v": chip8.KeyF,
-			"ArrowUp": chip8.Key5, "ArrowDown": chip8.Key8, "ArrowLeft": chip8.Key7, "ArrowRight": chip8.Key9,
-			" ": chip8.Key6,
-		},
 	}
 
 	window.Call("addEventListener", "keydown", js.FuncOf(i.onKeyDown))
@@ -32,8 +26,12 @@ func newInput(window js.Value, keyChan chan KeyEvent) Input {
 	return i
 }
 
+// KeyEvent is a key going down or up, named as in host.NormalizeKeyName.
+// Screen is set for the on-screen keypad, whose keys are named as in the
+// QWERTY layout whatever the keyboard layout.
 type KeyEvent struct {
-	Key     chip8.Key
+	Name    string
+	Screen  bool
 	Pressed bool
 }
 
@@ -56,11 +54,28 @@ func (i *Input) onKey(event js.Value, pressed bool) any {
 		return nil
 	}
 
-	key := event.Get("key").String()
-	if k, ok := i.keymap[key]; ok {
-		i.keyChan <- KeyEvent{Key: k, Pressed: pressed}
+	evt := KeyEvent{Name: keyName(event), Screen: !event.Get("isTrusted").Bool(), Pressed: pressed}
+	bound := i.mapper.Bound(evt.Name)
+	if evt.Screen {
+		_, bound = host.LayoutKey(host.LayoutQWERTY, evt.Name)
+	}
+	if bound {
+		i.keyChan <- evt
 		event.Call("preventDefault")
 	}
 
 	return nil
 }
+
+// keyName returns the layout's name of the key, except for the digit row,
+// the numpad and modifiers, whose key names depend on Shift or NumLock or
+// do not tell left from right.
+func keyName(event js.Value) string {
+	code := event.Get("code").String()
+	switch {
+	case strings.HasPrefix(code, "Digit"), strings.HasPrefix(code, "Numpad"),
+		strings.HasPrefix(code, "Shift"), strings.HasPrefix(code, "Control"), strings.HasPrefix(code, "Alt"):
+		return code
+	}
+	return event.Get("key").String()
+}
@@ -16,6 +16,7 @@ type Painter struct {
 	imageData     js.Value
 	screen        js.Value
 	canvas        js.Value
+	scaleInput    js.Value
 	screenBgColor *host.Color
 	width         int
 	height        int
@@ -29,32 +30,34 @@ func (p *Painter) setScreenBg(color host.Color) {
 	}
 }
 
-func newPainter(w, h int) (Painter, error) {
-	p := Painter{}
-	p.width = w
-	p.height = h
+func newPainter(w, h int) (*Painter, error) {
+	p := &Painter{}
 	doc := js.Global().Get("document")
 
 	p.canvas = doc.Call("getElementById", "chip8-canvas")
-	p.canvas.Set("width", w)
-	p.canvas.Set("height", h)
-
 	p.screen = doc.Call("getElementById", "chip8-screen")
+	p.ctx = p.canvas.Call("getContext", "2d")
+	p.scaleInput = doc.Call("getElementById", "scaleInput")
+	p.resize(w, h)
 
-	scaleInput := doc.Call("getElementById", "scaleInput")
-	value := scaleInput.Get("value").String()
-	p.setScale(value)
-	scaleInput.Call("addEventListener", "input", js.FuncOf(func(this js.Value, args []js.Value) any {
-		value := scaleInput.Get("value").String()
-		p.setScale(value)
+	p.scaleInput.Call("addEventListener", "input", js.FuncOf(func(this js.Value, args []js.Value) any {
+		p.setScale(p.scaleInput.Get("value").String())
 		return nil
 	}))
 
-	p.ctx = p.canvas.Call("getContext", "2d")
-	p.imageData = p.ctx.Call("createImageData", w, h)
 	return p, nil
 }
 
+// resize sets the canvas to a w×h frame, for example after a rotation.
+func (p *Painter) resize(w, h int) {
+	p.width = w
+	p.height = h
+	p.canvas.Set("width", w)
+	p.canvas.Set("height", h)
+	p.imageData = p.ctx.Call("createImageData", w, h)
+	p.setScale(p.scaleInput.Get("value").String())
+}
+
 func (p *Painter) setScale(value string) {
 	scale, err := strconv.Atoi(value)
 	if err != nil {
@@ -71,6 +74,9 @@ func (p *Painter) setScale(value string) {
 }
 
 func (p *Painter) Paint(fb *host.FrameBuffer) {
+	if fb.Width != p.width || fb.Height != p.height {
+		p.resize(fb.Width, fb.Height)
+	}
 	p.setScreenBg(fb.SoundColor)
 	js.CopyBytesToJS(p.imageData.Get("data"), fb.Pixels)
 	p.ctx.Call("putImageData", p.imageData, 0, 0)
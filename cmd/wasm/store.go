@@ -0,0 +1,32 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"encoding/base64"
+	"io/fs"
+	"syscall/js"
+)
+
+// LocalStore is a host.Store backed by the browser's localStorage. Values
+// are base64 encoded since localStorage only holds strings.
+type LocalStore struct {
+	storage js.Value
+}
+
+func newLocalStore(win js.Value) LocalStore {
+	return LocalStore{storage: win.Get("localStorage")}
+}
+
+func (s LocalStore) Load(key string) ([]byte, error) {
+	v := s.storage.Call("getItem", "ch8go/"+key)
+	if v.IsNull() {
+		return nil, fs.ErrNotExist
+	}
+	return base64.StdEncoding.DecodeString(v.String())
+}
+
+func (s LocalStore) Save(key string, data []byte) error {
+	s.storage.Call("setItem", "ch8go/"+key, base64.StdEncoding.EncodeToString(data))
+	return nil
+}
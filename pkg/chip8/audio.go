@@ -64,6 +64,8 @@ func (a *Audio) TickTimer() bool {
 	return false
 }
 
+func (a *Audio) Mode() AudioMode { return a.mode }
+
 func (a *Audio) SetMode(mode AudioMode) {
 	a.mode = mode
 	switch mode {
@@ -14,7 +14,8 @@ type CPU struct {
 	sp     byte
 	stack  [256]uint16 // original is 16 but modern games require deeper stack
 	dt     byte
-	flags  [16]byte // xochip ext, schip has 8
+	flags  [16]byte   // xochip ext, schip has 8
+	rng    *rand.Rand // nil uses the global source
 	Quirks Quirks
 }
 
@@ -25,6 +26,32 @@ func NewCpu(quirks Quirks) CPU {
 	}
 }
 
+// Seed makes RND deterministic by giving the CPU its own random source.
+func (c *CPU) Seed(seed int64) {
+	c.rng = rand.New(rand.NewSource(seed))
+}
+
+// V returns register Vx.
+func (c *CPU) V(x byte) byte {
+	return c.v[x&0xF]
+}
+
+// SetV sets register Vx, for debuggers and cheats.
+func (c *CPU) SetV(x, val byte) {
+	c.v[x&0xF] = val
+}
+
+// Flags returns the RPL user flags written by FX75. Unlike the other
+// registers they survive Reset, as on the HP48.
+func (c *CPU) Flags() [16]byte {
+	return c.flags
+}
+
+// SetFlags replaces the RPL user flags, e.g. with ones saved for a ROM.
+func (c *CPU) SetFlags(flags [16]byte) {
+	c.flags = flags
+}
+
 func (c *CPU) Reset() {
 	for i := range c.v {
 		c.v[i] = 0
@@ -168,6 +195,43 @@ func (c *CPU) Execute(op uint16, memory *Memory, display *Display, keypad *Keypa
 	}
 }
 
+// KnownOp reports whether op is an instruction Execute implements. Other
+// opcodes are executed as no-ops.
+func KnownOp(op uint16) bool {
+	n := op & 0x000F
+	nn := op & 0x00FF
+
+	switch op & 0xF000 {
+	case 0x0000:
+		if op&0x0F00 != 0 {
+			return false
+		}
+		switch nn {
+		case 0xE0, 0xEE, 0xFB, 0xFC, 0xFD, 0xFE, 0xFF:
+			return true
+		}
+		return nn&0xF0 == 0xC0 || nn&0xF0 == 0xD0
+	case 0x5000:
+		return n == 0x0 || n == 0x2 || n == 0x3
+	case 0x8000:
+		return n <= 0x7 || n == 0xE
+	case 0x9000:
+		return n == 0x0
+	case 0xE000:
+		return nn == 0x9E || nn == 0xA1
+	case 0xF000:
+		switch nn {
+		case 0x01, 0x07, 0x0A, 0x15, 0x18, 0x1E, 0x29, 0x30, 0x33, 0x3A, 0x55, 0x65, 0x75, 0x85:
+			return true
+		case 0x00, 0x02:
+			return op&0x0F00 == 0
+		}
+		return false
+	}
+
+	return true
+}
+
 func (c *CPU) opJP(op uint16) {
 	var v byte
 	if c.Quirks.Jump {
@@ -188,7 +252,13 @@ func (c *CPU) opADD(op uint16) {
 func (c *CPU) opRND(op uint16) {
 	x := read_x(op)
 	nn := read_nn(op)
-	c.v[x] = byte(rand.Intn(256)) & nn
+	var r int
+	if c.rng != nil {
+		r = c.rng.Intn(256)
+	} else {
+		r = rand.Intn(256)
+	}
+	c.v[x] = byte(r) & nn
 }
 
 func (c *CPU) opDRAW(op uint16, memory *Memory, display *Display) {
@@ -414,11 +484,11 @@ func (c *CPU) op5XY2(mem *Memory, op uint16) {
 
 	if x < y {
 		for z := 0; z <= dist; z++ {
-			mem.bytes[c.i+uint16(z)] = c.v[int(x)+z]
+			mem.Write(c.i+uint16(z), c.v[int(x)+z])
 		}
 	} else {
 		for z := 0; z <= dist; z++ {
-			mem.bytes[c.i+uint16(z)] = c.v[int(x)-z]
+			mem.Write(c.i+uint16(z), c.v[int(x)-z])
 		}
 	}
 }
@@ -435,11 +505,11 @@ func (c *CPU) op5XY3(mem *Memory, op uint16) {
 
 	if x < y {
 		for z := 0; z <= dist; z++ {
-			c.v[int(x)+z] = mem.bytes[c.i+uint16(z)]
+			c.v[int(x)+z] = mem.Read(c.i + uint16(z))
 		}
 	} else {
 		for z := 0; z <= dist; z++ {
-			c.v[int(x)-z] = mem.bytes[c.i+uint16(z)]
+			c.v[int(x)-z] = mem.Read(c.i + uint16(z))
 		}
 	}
 }
@@ -464,18 +534,28 @@ func read_nnn(op uint16) uint16 {
 	return op & 0x0FFF
 }
 
+// push drops the value when the stack is full rather than wrapping sp.
 func (c *CPU) push(val uint16) {
+	if int(c.sp) >= len(c.stack)-1 {
+		return
+	}
 	c.stack[c.sp] = val
 	c.sp += 1
 }
 
-func (c *CPU) pop() uint16 {
+// pop reports false on an empty stack rather than wrapping sp.
+func (c *CPU) pop() (uint16, bool) {
+	if c.sp == 0 {
+		return 0, false
+	}
 	c.sp -= 1
-	return c.stack[c.sp]
+	return c.stack[c.sp], true
 }
 
 func (c *CPU) ret() {
-	c.pc = c.pop()
+	if addr, ok := c.pop(); ok {
+		c.pc = addr
+	}
 }
 
 func (c *CPU) call(addr uint16) {
@@ -21,6 +21,21 @@ func TestOpRND(t *testing.T) {
 	}
 }
 
+func TestCPUSeed(t *testing.T) {
+	a := NewCpu(DefaultConf.Quirks)
+	b := NewCpu(DefaultConf.Quirks)
+	a.Seed(42)
+	b.Seed(42)
+
+	for i := 0; i < 16; i++ {
+		a.opRND(0xC0FF)
+		b.opRND(0xC0FF)
+		if a.v[0] != b.v[0] {
+			t.Fatalf("step %d: seeded CPUs diverged: %#02x != %#02x", i, a.v[0], b.v[0])
+		}
+	}
+}
+
 func TestOpF000(t *testing.T) {
 	vm := NewVM()
 	// F000 reads the following word as a 16-bit address into I.
@@ -72,3 +87,47 @@ func TestVMTickrate(t *testing.T) {
 		t.Errorf("Tickrate() = %d, want 30", got)
 	}
 }
+
+func TestKnownOp(t *testing.T) {
+	known := []uint16{0x00E0, 0x00EE, 0x00C4, 0x00D2, 0x00FF, 0x1234, 0x5122, 0x8AB6, 0x8ABE, 0x9120, 0xE19E, 0xF000, 0xF002, 0xF301, 0xF130, 0xF275, 0xF285}
+	unknown := []uint16{0x0123, 0x01E0, 0x5121, 0x8AB8, 0x9121, 0xE1A2, 0xF102, 0xF1FF}
+
+	for _, op := range known {
+		if !KnownOp(op) {
+			t.Errorf("KnownOp(%04X) = false, want true", op)
+		}
+	}
+	for _, op := range unknown {
+		if KnownOp(op) {
+			t.Errorf("KnownOp(%04X) = true, want false", op)
+		}
+	}
+}
+
+func TestReadSpriteWraps(t *testing.T) {
+	m := NewMemory()
+	m.Write(0xFFFF, 0xAA)
+	m.Write(0x0000, 0xBB)
+
+	got := m.ReadSprite(0xFFFF, 2)
+	if len(got) != 2 || got[0] != 0xAA || got[1] != 0xBB {
+		t.Errorf("ReadSprite(FFFF, 2) = % X, want AA BB", got)
+	}
+}
+
+func TestStackBounds(t *testing.T) {
+	c := NewCpu(DefaultConf.Quirks)
+	c.pc = 0x300
+
+	c.ret()
+	if c.pc != 0x300 || c.sp != 0 {
+		t.Errorf("RET on empty stack: pc=%04X sp=%d, want pc=0300 sp=0", c.pc, c.sp)
+	}
+
+	for range len(c.stack) + 1 {
+		c.call(0x400)
+	}
+	if int(c.sp) != len(c.stack)-1 {
+		t.Errorf("sp after overflow = %d, want %d", c.sp, len(c.stack)-1)
+	}
+}
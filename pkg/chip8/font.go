@@ -0,0 +1,110 @@
+package chip8
+
+import "slices"
+
+// FontStyle names a built-in hex digit font, using the style names of the
+// CHIP-8 database and Octo.
+type FontStyle string
+
+const (
+	FontSChip FontStyle = "schip" // the default
+	FontOcto  FontStyle = "octo"
+	FontVIP   FontStyle = "vip"
+	FontFish  FontStyle = "fish"
+)
+
+// font holds the small 4x5 digits and the large 8x10 digits loaded below
+// ProgramStart.
+type font struct {
+	small [smallFontSize]byte
+	big   [bigFontSize]byte
+}
+
+// fonts lists the supported styles. Styles with no large font of their own
+// keep the SUPER-CHIP one.
+var fonts = map[FontStyle]font{
+	FontSChip: {
+		small: [smallFontSize]byte(fontSets[:smallFontSize]),
+		big:   [bigFontSize]byte(fontSets[smallFontSize:]),
+	},
+	FontOcto: {
+		small: [smallFontSize]byte(fontSets[:smallFontSize]),
+		big: [bigFontSize]byte{
+			0xFF, 0xFF, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF, // 0
+			0x18, 0x78, 0x78, 0x18, 0x18, 0x18, 0x18, 0x18, 0xFF, 0xFF, // 1
+			0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, // 2
+			0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, // 3
+			0xC3, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF, 0x03, 0x03, 0x03, 0x03, // 4
+			0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, // 5
+			0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, // 6
+			0xFF, 0xFF, 0x03, 0x03, 0x06, 0x0C, 0x18, 0x18, 0x18, 0x18, // 7
+			0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, // 8
+			0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, // 9
+			0x7E, 0xFF, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF, 0xC3, 0xC3, 0xC3, // A
+			0xFC, 0xFC, 0xC3, 0xC3, 0xFC, 0xFC, 0xC3, 0xC3, 0xFC, 0xFC, // B
+			0x3C, 0xFF, 0xC3, 0xC0, 0xC0, 0xC0, 0xC0, 0xC3, 0xFF, 0x3C, // C
+			0xFC, 0xFE, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xFE, 0xFC, // D
+			0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, // E
+			0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC0, 0xC0, 0xC0, 0xC0, // F
+		},
+	},
+	FontVIP: {
+		small: [smallFontSize]byte{
+			0xF0, 0x90, 0x90, 0x90, 0xF0, // 0
+			0x60, 0x20, 0x20, 0x20, 0x70, // 1
+			0xF0, 0x10, 0xF0, 0x80, 0xF0, // 2
+			0xF0, 0x10, 0xF0, 0x10, 0xF0, // 3
+			0xA0, 0xA0, 0xF0, 0x20, 0x20, // 4
+			0xF0, 0x80, 0xF0, 0x10, 0xF0, // 5
+			0xF0, 0x80, 0xF0, 0x90, 0xF0, // 6
+			0xF0, 0x10, 0x10, 0x10, 0x10, // 7
+			0xF0, 0x90, 0xF0, 0x90, 0xF0, // 8
+			0xF0, 0x90, 0xF0, 0x10, 0xF0, // 9
+			0xF0, 0x90, 0xF0, 0x90, 0x90, // A
+			0xF0, 0x50, 0x70, 0x50, 0xF0, // B
+			0xF0, 0x80, 0x80, 0x80, 0xF0, // C
+			0xF0, 0x50, 0x50, 0x50, 0xF0, // D
+			0xF0, 0x80, 0xF0, 0x80, 0xF0, // E
+			0xF0, 0x80, 0xF0, 0x80, 0x80, // F
+		},
+		big: [bigFontSize]byte(fontSets[smallFontSize:]),
+	},
+	FontFish: {
+		small: [smallFontSize]byte{
+			0x60, 0xA0, 0xA0, 0xA0, 0xC0, // 0
+			0x40, 0xC0, 0x40, 0x40, 0xE0, // 1
+			0xC0, 0x20, 0x40, 0x80, 0xE0, // 2
+			0xC0, 0x20, 0x40, 0x20, 0xC0, // 3
+			0x20, 0xA0, 0xE0, 0x20, 0x20, // 4
+			0xE0, 0x80, 0xC0, 0x20, 0xC0, // 5
+			0x40, 0x80, 0xC0, 0xA0, 0x40, // 6
+			0xE0, 0x20, 0x60, 0x40, 0x40, // 7
+			0x40, 0xA0, 0x40, 0xA0, 0x40, // 8
+			0x40, 0xA0, 0x60, 0x20, 0x40, // 9
+			0x40, 0xA0, 0xE0, 0xA0, 0xA0, // A
+			0xC0, 0xA0, 0xC0, 0xA0, 0xC0, // B
+			0x60, 0x80, 0x80, 0x80, 0x60, // C
+			0xC0, 0xA0, 0xA0, 0xA0, 0xC0, // D
+			0xE0, 0x80, 0xC0, 0x80, 0xE0, // E
+			0xE0, 0x80, 0xC0, 0x80, 0x80, // F
+		},
+		big: [bigFontSize]byte(fontSets[smallFontSize:]),
+	},
+}
+
+// FontStyles returns the supported font styles in sorted order.
+func FontStyles() []FontStyle {
+	styles := make([]FontStyle, 0, len(fonts))
+	for s := range fonts {
+		styles = append(styles, s)
+	}
+	slices.Sort(styles)
+	return styles
+}
+
+// ValidFont reports whether s is a supported style. The empty style is the
+// default.
+func ValidFont(s FontStyle) bool {
+	_, ok := fonts[s]
+	return ok || s == ""
+}
@@ -0,0 +1,56 @@
+package chip8
+
+import (
+	"testing"
+	"time"
+)
+
+// fuzzFrames bounds how long FuzzVM runs each input.
+const fuzzFrames = 8
+
+func FuzzExecute(f *testing.F) {
+	for _, op := range []uint16{0x00E0, 0x00EE, 0x00C5, 0x00FD, 0x5AF2, 0x5AF3, 0xD00F, 0xD000, 0xF000, 0xF002, 0xFF33, 0xFF55, 0xFF65} {
+		f.Add(op, uint16(0xFFFF), byte(0), []byte{0xFF, 0x01})
+	}
+
+	f.Fuzz(func(t *testing.T, op, i uint16, sp byte, regs []byte) {
+		vm := NewVM()
+		vm.CPU.i = i
+		vm.CPU.sp = sp
+		vm.CPU.pc = i
+		copy(vm.CPU.v[:], regs)
+
+		vm.CPU.Execute(op, &vm.Memory, &vm.Display, &vm.Keypad, &vm.Audio)
+	})
+}
+
+func FuzzVM(f *testing.F) {
+	f.Add([]byte{0x00, 0xEE}, byte(0))
+	f.Add([]byte{0xAF, 0xFF, 0xD0, 0x0F, 0x12, 0x00}, byte(1))
+	f.Add([]byte{0xF0, 0x00, 0xFF, 0xFF, 0x6F, 0x0F, 0xF3, 0x01, 0xD0, 0x00}, byte(2))
+
+	platforms := []Platform{PlatformChip8, PlatformSChip11, PlatformXOChip}
+
+	f.Fuzz(func(t *testing.T, rom []byte, platform byte) {
+		vm := NewVM()
+		if err := vm.LoadROM(rom); err != nil {
+			return
+		}
+		vm.SetConf(ConfByPlatform[platforms[int(platform)%len(platforms)]])
+
+		for range fuzzFrames {
+			vm.RunFrame(time.Second / 60)
+		}
+	})
+}
+
+func FuzzDisasm(f *testing.F) {
+	f.Add(uint16(0x00E0))
+	f.Add(uint16(0xF000))
+
+	f.Fuzz(func(t *testing.T, op uint16) {
+		if Disasm(op) == "" {
+			t.Errorf("Disasm(%04X) is empty", op)
+		}
+	})
+}
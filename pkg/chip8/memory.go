@@ -9,8 +9,9 @@ const ProgramStart = 0x200
 const fontAddr = 0x050
 const smallFontSize = 80
 const bigFontAddr = fontAddr + smallFontSize
+const bigFontSize = 160
 
-var fontSets = [240]byte{
+var fontSets = [smallFontSize + bigFontSize]byte{
 	0xF0, 0x90, 0x90, 0x90, 0xF0, // 0
 	0x20, 0x60, 0x20, 0x20, 0x70, // 1
 	0xF0, 0x10, 0xF0, 0x80, 0xF0, // 2
@@ -50,6 +51,7 @@ var fontSets = [240]byte{
 // Memory represents the CHIP-8 address space.
 type Memory struct {
 	bytes [MemorySize]byte
+	font  FontStyle
 }
 
 func NewMemory() Memory {
@@ -82,9 +84,18 @@ func (m *Memory) Write(addr uint16, val byte) {
 	m.bytes[addr] = val
 }
 
-func (m *Memory) ReadSprite(i uint16, height uint16) []byte {
-	// NOTE: i and height are always valid in real CHIP-8 ROMs
-	return m.bytes[i : i+height]
+// ReadSprite returns n bytes starting at i. Reads past the end of memory
+// wrap around to address 0, like every other 16-bit address.
+func (m *Memory) ReadSprite(i uint16, n uint16) []byte {
+	end := int(i) + int(n)
+	if end <= MemorySize {
+		return m.bytes[i:end]
+	}
+
+	sprite := make([]byte, n)
+	k := copy(sprite, m.bytes[i:])
+	copy(sprite[k:], m.bytes[:])
+	return sprite
 }
 
 func (m *Memory) ReadU16(addr uint16) uint16 {
@@ -93,6 +104,23 @@ func (m *Memory) ReadU16(addr uint16) uint16 {
 	return uint16(hi)<<8 | uint16(lo)
 }
 
+// SetFont loads the given font style, which is kept across resets. Unknown
+// styles load the default one.
+func (m *Memory) SetFont(style FontStyle) {
+	m.font = style
+	m.loadFont()
+}
+
+// Font returns the font style set by SetFont.
+func (m *Memory) Font() FontStyle {
+	return m.font
+}
+
 func (m *Memory) loadFont() {
-	copy(m.bytes[fontAddr:], fontSets[:])
+	f, ok := fonts[m.font]
+	if !ok {
+		f = fonts[FontSChip]
+	}
+	copy(m.bytes[fontAddr:], f.small[:])
+	copy(m.bytes[bigFontAddr:], f.big[:])
 }
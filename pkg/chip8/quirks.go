@@ -29,6 +29,7 @@ type PlatformConf struct {
 	Quirks    Quirks
 	Tickrate  int
 	AudioMode AudioMode
+	Font      FontStyle // empty for the default
 }
 
 func (c *PlatformConf) CPUHz() float64 {
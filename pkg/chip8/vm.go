@@ -27,6 +27,7 @@ type VM struct {
 	Display    Display
 	Keypad     Keypad
 	Audio      Audio
+	OnStep     func(pc, op uint16) // optional; called before each executed instruction
 	romSize    int
 	cpuHz      float64
 	cycleAccum float64
@@ -48,6 +49,17 @@ func (vm *VM) SetConf(conf PlatformConf) {
 	vm.SetQuirks(conf.Quirks)
 	vm.SetTickrate(conf.Tickrate)
 	vm.Audio.SetMode(conf.AudioMode)
+	vm.Memory.SetFont(conf.Font)
+}
+
+// Conf returns the configuration the VM runs with.
+func (vm *VM) Conf() PlatformConf {
+	return PlatformConf{
+		Quirks:    vm.CPU.Quirks,
+		Tickrate:  vm.Tickrate(),
+		AudioMode: vm.Audio.Mode(),
+		Font:      vm.Memory.Font(),
+	}
 }
 
 func (vm *VM) Tickrate() int      { return int(vm.cpuHz / 60.0) }
@@ -78,7 +90,11 @@ func (vm *VM) Reset() {
 
 func (vm *VM) Step() {
 	if !vm.Display.pendingVBlank || !vm.CPU.Quirks.WaitVBlank {
+		pc := vm.CPU.pc
 		opcode := vm.CPU.fetch(&vm.Memory)
+		if vm.OnStep != nil {
+			vm.OnStep(pc, opcode)
+		}
 		vm.CPU.Execute(opcode, &vm.Memory, &vm.Display, &vm.Keypad, &vm.Audio)
 	}
 }
@@ -60,3 +60,30 @@ func TestRunWatch(t *testing.T) {
 		t.Errorf("WatchDesc = %q, want V0", res.WatchDesc)
 	}
 }
+
+func TestFontStyle(t *testing.T) {
+	vm := NewVM()
+	conf := DefaultConf
+	conf.Font = FontVIP
+	vm.SetConf(conf)
+
+	// The VIP "1" is centered one pixel to the right of the default one.
+	if got := vm.Memory.Read(fontAddr + 5); got != 0x60 {
+		t.Errorf("VIP digit 1 = %02X, want 60", got)
+	}
+	if err := vm.LoadROM([]byte{0x12, 0x00}); err != nil {
+		t.Fatal(err)
+	}
+	if got := vm.Memory.Read(fontAddr + 5); got != 0x60 {
+		t.Errorf("font style not kept across LoadROM: digit 1 = %02X", got)
+	}
+
+	vm.Memory.SetFont(FontOcto)
+	if got := vm.Memory.Read(bigFontAddr); got != 0xFF {
+		t.Errorf("Octo big digit 0 = %02X, want FF", got)
+	}
+	vm.Memory.SetFont("unknown")
+	if got := vm.Memory.Read(fontAddr + 5); got != 0x20 {
+		t.Errorf("unknown style should load the default font, digit 1 = %02X", got)
+	}
+}
@@ -114,3 +114,57 @@ func TestROMMetaKeysInfo(t *testing.T) {
 		t.Errorf("KeysInfo() = %q, want to contain %q", got, "up: 5")
 	}
 }
+
+func TestFullSchema(t *testing.T) {
+	db, err := NewMetaDB()
+	if err != nil {
+		t.Fatalf("NewMetaDB() error = %v", err)
+	}
+
+	tests := []struct {
+		hash  string
+		check func(*ProgramMeta, *ROMMeta) bool
+	}{
+		{"64536d549c986e9edf25de9fa89db60d2ade85c0", func(_ *ProgramMeta, r *ROMMeta) bool {
+			return r.ScreenRotation == 270
+		}},
+		{"24ef21009527ee674de44ccb37e37081654883f9", func(_ *ProgramMeta, r *ROMMeta) bool {
+			return r.Tickrate == 200000 // spelled "tickRate"
+		}},
+		{"df5ced9c20d00bf7be7d3361d76f27d0d577abfb", func(_ *ProgramMeta, r *ROMMeta) bool {
+			ids := r.PlatformIDs()
+			q, ok := r.QuirkyPlatforms["superchip"]["shift"]
+			return len(ids) == 1 && ids[0] == "superchip" && ok && !q
+		}},
+		{"a2788177b820a28cd27e6d2d180340cb7f4948fb", func(_ *ProgramMeta, r *ROMMeta) bool {
+			return len(r.Authors) == 2 && r.Release == "2006-02-17"
+		}},
+	}
+
+	for _, tt := range tests {
+		p, r := db.Program(tt.hash), db.ROM(tt.hash)
+		if p == nil || r == nil {
+			t.Errorf("%s not found", tt.hash)
+			continue
+		}
+		if !tt.check(p, r) {
+			t.Errorf("%s (%s): unexpected %+v", tt.hash, p.Title, r)
+		}
+	}
+
+	var origin, images, urls, copyright, fonts, touch bool
+	for _, p := range db.programs {
+		origin = origin || p.Origin != nil && p.Origin.Type != ""
+		images = images || len(p.Images) > 0
+		urls = urls || len(p.URLs) > 0
+		copyright = copyright || p.Copyright != ""
+		for _, r := range p.ROMs {
+			fonts = fonts || r.FontStyle != ""
+			touch = touch || r.TouchInputMode != ""
+		}
+	}
+	if !origin || !images || !urls || !copyright || !fonts || !touch {
+		t.Errorf("fields not parsed: origin %t, images %t, urls %t, copyright %t, fontStyle %t, touchInputMode %t",
+			origin, images, urls, copyright, fonts, touch)
+	}
+}
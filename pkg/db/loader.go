@@ -3,65 +3,153 @@ package db
 import (
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"slices"
 	"strings"
 )
 
-//go:embed programs.json platforms.json sha1-hashes.json
-var fs embed.FS
+// Database files, as published by the CHIP-8 community database.
+const (
+	programsFile  = "programs.json"
+	platformsFile = "platforms.json"
+	hashesFile    = "sha1-hashes.json"
+)
 
-func loadMeta(db *MetaDB) error {
-	if err := loadJSON("programs.json", &db.programs); err != nil {
-		return err
-	}
+//go:embed programs.json platforms.json sha1-hashes.json
+var embedded embed.FS
 
-	if err := loadJSON("platforms.json", &db.platforms); err != nil {
-		return err
+// loadMeta reads a database from fsys. Missing files are left empty when
+// optional is set, so a directory may hold just the files it updates.
+func loadMeta(db *MetaDB, fsys fs.FS, optional bool) error {
+	files := []struct {
+		name string
+		v    any
+	}{
+		{programsFile, &db.programs},
+		{platformsFile, &db.platforms},
+		{hashesFile, &db.hashes},
 	}
 
-	if err := loadJSON("sha1-hashes.json", &db.hashes); err != nil {
-		return err
+	for _, f := range files {
+		err := loadJSON(fsys, f.name, f.v)
+		if optional && errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func loadJSON(name string, v any) error {
-	data, err := fs.ReadFile(name)
+func loadJSON(fsys fs.FS, name string, v any) error {
+	data, err := fs.ReadFile(fsys, name)
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(data, v)
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
 }
 
 type ProgramMeta struct {
 	Title       string             `json:"title"`
+	Origin      *OriginMeta        `json:"origin,omitempty"`
 	Release     string             `json:"release"`
 	Authors     []string           `json:"authors"`
 	Description string             `json:"description"`
+	Images      []string           `json:"images,omitempty"` // file names in the database repository
+	URLs        []string           `json:"urls,omitempty"`
+	Copyright   string             `json:"copyright,omitempty"`
+	License     string             `json:"license,omitempty"`
 	ROMs        map[string]ROMMeta `json:"roms"`
 }
 
+// OriginMeta tells where a program was published.
+type OriginMeta struct {
+	Type      string `json:"type"`                // e.g. "gamejam", "event" or "manual"
+	Reference string `json:"reference,omitempty"` // e.g. "Octojam1"
+}
+
 func (p *ProgramMeta) Info() string {
 	authors := strings.Join(p.Authors, ", ")
 
-	return fmt.Sprintf(
-		"%s\nReleased: %s\nAuthors: %s\n\n%s",
+	info := fmt.Sprintf(
+		"%s\nReleased: %s\nAuthors: %s\n",
 		p.Title,
 		p.Release,
 		authors,
-		p.Description,
 	)
+	if p.Origin != nil {
+		info += fmt.Sprintf("Origin: %s %s\n", p.Origin.Type, p.Origin.Reference)
+	}
+	if p.Copyright != "" {
+		info += fmt.Sprintf("Copyright: %s\n", p.Copyright)
+	}
+	for _, u := range p.URLs {
+		info += u + "\n"
+	}
+
+	return info + "\n" + p.Description
 }
 
 type ROMMeta struct {
-	File          string         `json:"file"`
-	Platforms     []string       `json:"platforms"`
-	Description   string         `json:"description,omitempty"`
-	EmbeddedTitle string         `json:"embeddedTitle,omitempty"`
-	Tickrate      int            `json:"tickrate"`
-	Colors        *ROMColorsMeta `json:"colors,omitempty"`
-	Keys          map[string]int `json:"keys"`
+	File          string   `json:"file"`
+	Platforms     []string `json:"platforms"`
+	Description   string   `json:"description,omitempty"`
+	EmbeddedTitle string   `json:"embeddedTitle,omitempty"`
+	Release       string   `json:"release,omitempty"` // when it differs from the program
+	Authors       []string `json:"authors,omitempty"` // when they differ from the program
+	Images        []string `json:"images,omitempty"`
+	URLs          []string `json:"urls,omitempty"`
+	// QuirkyPlatforms lists platforms the ROM only runs on with some of
+	// their quirks changed, by platform id.
+	QuirkyPlatforms map[string]QuirkOverrides `json:"quirkyPlatforms,omitempty"`
+	Tickrate        int                       `json:"tickrate"`
+	ScreenRotation  int                       `json:"screenRotation,omitempty"` // clockwise degrees
+	FontStyle       string                    `json:"fontStyle,omitempty"`      // e.g. "octo" or "fish"
+	TouchInputMode  string                    `json:"touchInputMode,omitempty"` // e.g. "none", "swipe" or "gamepad"
+	Colors          *ROMColorsMeta            `json:"colors,omitempty"`
+	Keys            map[string]int            `json:"keys"`
+}
+
+// QuirkOverrides maps quirk names as in QuirksMeta, such as "shift" or
+// "memoryLeaveIUnchanged", to the values a ROM needs.
+type QuirkOverrides map[string]bool
+
+// UnmarshalJSON also accepts the "tickRate" spelling found in some entries.
+// With both spellings present "tickrate" wins.
+func (r *ROMMeta) UnmarshalJSON(data []byte) error {
+	type rom ROMMeta
+	var v struct {
+		rom
+		TickRate int `json:"tickRate"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*r = ROMMeta(v.rom)
+	if r.Tickrate == 0 {
+		r.Tickrate = v.TickRate
+	}
+	return nil
+}
+
+// PlatformIDs returns the platforms the ROM runs on followed by its quirky
+// platforms, without duplicates.
+func (r *ROMMeta) PlatformIDs() []string {
+	ids := slices.Clone(r.Platforms)
+	for _, id := range sortedKeys(r.QuirkyPlatforms) {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
 }
 
 func (r *ROMMeta) KeysInfo() (keys string) {
@@ -91,6 +179,18 @@ type PlatformMeta struct {
 	Quirks             QuirksMeta `json:"quirks"`
 }
 
+// QuirkNames lists the quirk names of QuirksMeta in field order.
+var QuirkNames = []string{
+	"shift",
+	"memoryIncrementByX",
+	"memoryLeaveIUnchanged",
+	"wrap",
+	"jump",
+	"vblank",
+	"logic",
+	"scaleScroll",
+}
+
 type QuirksMeta struct {
 	Shift                 bool `json:"shift"`
 	MemoryIncrementByX    bool `json:"memoryIncrementByX"`
@@ -9,25 +9,40 @@ type MetaDB struct {
 	programs  []ProgramMeta
 	platforms []PlatformMeta
 	hashes    map[string]int
+	conflicts []Conflict
+	idx       index
 }
 
-func NewMetaDB() (*MetaDB, error) {
+// NewMetaDB loads the embedded database and then each source in turn, see
+// Source.
+func NewMetaDB(sources ...Source) (*MetaDB, error) {
 	db := MetaDB{}
-	if err := loadMeta(&db); err != nil {
+	if err := loadMeta(&db, embedded, false); err != nil {
 		return nil, err
 	}
-	return &db, nil
-}
 
-func (db *MetaDB) Platform(id string) (platform *PlatformMeta) {
-	for i := range db.platforms {
-		if db.platforms[i].ID == id {
-			platform = &db.platforms[i]
-			break
+	for _, src := range sources {
+		if err := db.load(src); err != nil {
+			return nil, fmt.Errorf("database %s: %w", src.Dir, err)
 		}
 	}
 
-	return platform
+	db.buildIndex()
+	return &db, nil
+}
+
+// Conflicts lists the entries of loaded sources that replaced different
+// ones, in load order.
+func (db *MetaDB) Conflicts() []Conflict {
+	return db.conflicts
+}
+
+func (db *MetaDB) Platform(id string) *PlatformMeta {
+	i, ok := db.idx.platforms[id]
+	if !ok {
+		return nil
+	}
+	return &db.platforms[i]
 }
 
 func (db *MetaDB) ROM(hash string) *ROMMeta {
@@ -0,0 +1,299 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Feature flags ROM metadata beyond the platform.
+type Feature uint8
+
+const (
+	FeatureColors   Feature = 1 << iota // custom palette
+	FeatureKeys                         // documented key bindings
+	FeatureTickrate                     // explicit tickrate
+)
+
+var featureNames = map[string]Feature{
+	"colors":   FeatureColors,
+	"keys":     FeatureKeys,
+	"tickrate": FeatureTickrate,
+}
+
+func (r *ROMMeta) features() Feature {
+	var f Feature
+	if r.Colors != nil && len(r.Colors.Pixels) > 0 {
+		f |= FeatureColors
+	}
+	if len(r.Keys) > 0 {
+		f |= FeatureKeys
+	}
+	if r.Tickrate > 0 {
+		f |= FeatureTickrate
+	}
+	return f
+}
+
+// Query selects programs for MetaDB.Search. Zero fields match everything.
+type Query struct {
+	Title    string  // case-insensitive substring of the title
+	Fuzzy    bool    // also match Title as a subsequence, ranking by closeness
+	Author   string  // case-insensitive substring of any author
+	Year     int     // release year
+	Platform string  // platform or quirky platform id of a ROM
+	Features Feature // all of these on a ROM
+	Limit    int     // maximum results; 0 is unlimited
+}
+
+// Result is a program matching a Query with the hashes of its matching ROMs
+// in ascending order.
+type Result struct {
+	Program *ProgramMeta
+	Hashes  []string
+	score   int
+}
+
+// ParseQuery reads a query such as "tetris author:fran year:1991
+// platform:superchip has:colors,keys". Words without a key form the title,
+// which is matched fuzzily.
+func ParseQuery(s string) (Query, error) {
+	q := Query{Fuzzy: true}
+	var title []string
+
+	for _, word := range strings.Fields(s) {
+		key, value, ok := strings.Cut(word, ":")
+		if !ok {
+			title = append(title, word)
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "author":
+			q.Author = value
+		case "year":
+			y, err := strconv.Atoi(value)
+			if err != nil {
+				return q, fmt.Errorf("invalid year %q", value)
+			}
+			q.Year = y
+		case "platform":
+			q.Platform = value
+		case "has":
+			for _, name := range strings.Split(value, ",") {
+				f, ok := featureNames[strings.ToLower(name)]
+				if !ok {
+					return q, fmt.Errorf("unknown feature %q (use colors, keys or tickrate)", name)
+				}
+				q.Features |= f
+			}
+		default:
+			return q, fmt.Errorf("unknown query key %q (use author, year, platform or has)", key)
+		}
+	}
+
+	q.Title = strings.Join(title, " ")
+	return q, nil
+}
+
+// index holds lookups built once the database is loaded.
+type index struct {
+	platforms  map[string]int     // platform id → position in MetaDB.platforms
+	titles     []string           // lowercased titles by program
+	authors    map[string][]int   // lowercased author → programs
+	years      map[int][]int      // release year → programs
+	byPlatform map[string][]int   // platform id → programs with a ROM for it
+	features   map[string]Feature // hash → features
+	files      map[string]string  // lowercased ROM file name → hash
+}
+
+func (db *MetaDB) buildIndex() {
+	idx := index{
+		platforms:  make(map[string]int, len(db.platforms)),
+		titles:     make([]string, len(db.programs)),
+		authors:    map[string][]int{},
+		years:      map[int][]int{},
+		byPlatform: map[string][]int{},
+		features:   make(map[string]Feature, len(db.hashes)),
+		files:      make(map[string]string, len(db.hashes)),
+	}
+
+	for i, p := range db.platforms {
+		idx.platforms[p.ID] = i
+	}
+
+	for i := range db.programs {
+		p := &db.programs[i]
+		idx.titles[i] = strings.ToLower(p.Title)
+
+		for _, a := range p.Authors {
+			a = strings.ToLower(a)
+			idx.authors[a] = append(idx.authors[a], i)
+		}
+		if y, ok := releaseYear(p.Release); ok {
+			idx.years[y] = append(idx.years[y], i)
+		}
+
+		for _, h := range sortedKeys(p.ROMs) {
+			rom := p.ROMs[h]
+			idx.features[h] = rom.features()
+			if file := strings.ToLower(rom.File); file != "" {
+				if _, dup := idx.files[file]; !dup {
+					idx.files[file] = h
+				}
+			}
+			for _, id := range rom.PlatformIDs() {
+				progs := idx.byPlatform[id]
+				if len(progs) == 0 || progs[len(progs)-1] != i {
+					idx.byPlatform[id] = append(progs, i)
+				}
+			}
+		}
+	}
+
+	db.idx = idx
+}
+
+// HashByFile returns the hash of the ROM whose file name in the database is
+// name, compared case-insensitively. When several ROMs share a name the
+// first program listing one wins.
+func (db *MetaDB) HashByFile(name string) (string, bool) {
+	h, ok := db.idx.files[strings.ToLower(name)]
+	return h, ok
+}
+
+// releaseYear parses the year of release strings such as "1991" or
+// "2020-10-01"; unknown years like "19xx" have none.
+func releaseYear(release string) (int, bool) {
+	if len(release) < 4 {
+		return 0, false
+	}
+	y, err := strconv.Atoi(release[:4])
+	return y, err == nil
+}
+
+// Search returns the programs matching q, best fuzzy matches first and
+// otherwise ordered by title.
+func (db *MetaDB) Search(q Query) []Result {
+	candidates := db.candidates(q)
+	title := strings.ToLower(strings.TrimSpace(q.Title))
+	author := strings.ToLower(q.Author)
+
+	var results []Result
+	for _, i := range candidates {
+		p := &db.programs[i]
+
+		score := 0
+		if title != "" {
+			var ok bool
+			if score, ok = matchTitle(db.idx.titles[i], title, q.Fuzzy); !ok {
+				continue
+			}
+		}
+		if author != "" && !slices.ContainsFunc(p.Authors, func(a string) bool {
+			return strings.Contains(strings.ToLower(a), author)
+		}) {
+			continue
+		}
+
+		var hashes []string
+		for _, h := range sortedKeys(p.ROMs) {
+			rom := p.ROMs[h]
+			if q.Platform != "" && !slices.Contains(rom.PlatformIDs(), q.Platform) {
+				continue
+			}
+			if db.idx.features[h]&q.Features != q.Features {
+				continue
+			}
+			hashes = append(hashes, h)
+		}
+		if len(hashes) == 0 {
+			continue
+		}
+
+		results = append(results, Result{Program: p, Hashes: hashes, score: score})
+	}
+
+	slices.SortStableFunc(results, func(a, b Result) int {
+		if a.score != b.score {
+			return a.score - b.score
+		}
+		return strings.Compare(strings.ToLower(a.Program.Title), strings.ToLower(b.Program.Title))
+	})
+
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results
+}
+
+// candidates narrows the programs with the most selective index available.
+func (db *MetaDB) candidates(q Query) []int {
+	var sets [][]int
+
+	if q.Year != 0 {
+		sets = append(sets, db.idx.years[q.Year])
+	}
+	if q.Platform != "" {
+		sets = append(sets, db.idx.byPlatform[q.Platform])
+	}
+	if q.Author != "" {
+		author := strings.ToLower(q.Author)
+		var progs []int
+		for a, ps := range db.idx.authors {
+			if strings.Contains(a, author) {
+				progs = append(progs, ps...)
+			}
+		}
+		slices.Sort(progs)
+		sets = append(sets, slices.Compact(progs))
+	}
+
+	if len(sets) == 0 {
+		all := make([]int, len(db.programs))
+		for i := range all {
+			all[i] = i
+		}
+		return all
+	}
+
+	slices.SortFunc(sets, func(a, b []int) int { return len(a) - len(b) })
+	out := slices.Clone(sets[0])
+	for _, s := range sets[1:] {
+		out = slices.DeleteFunc(out, func(i int) bool {
+			_, found := slices.BinarySearch(s, i)
+			return !found
+		})
+	}
+	return out
+}
+
+// matchTitle reports whether title contains pattern, or with fuzzy holds
+// its characters in order. Lower scores are closer: substrings score their
+// position, subsequences rank after them by the characters skipped.
+func matchTitle(title, pattern string, fuzzy bool) (int, bool) {
+	if i := strings.Index(title, pattern); i >= 0 {
+		return i, true
+	}
+	if !fuzzy {
+		return 0, false
+	}
+
+	p := []rune(pattern)
+	skipped, j := 0, 0
+	for _, c := range title {
+		if j == len(p) {
+			break
+		}
+		if c == p[j] {
+			j++
+		} else if j > 0 {
+			skipped++
+		}
+	}
+	if j < len(p) {
+		return 0, false
+	}
+	return len(title) + skipped, true
+}
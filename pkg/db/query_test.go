@@ -0,0 +1,102 @@
+package db
+
+import (
+	"slices"
+	"testing"
+)
+
+func titles(results []Result) []string {
+	out := make([]string, len(results))
+	for i, r := range results {
+		out[i] = r.Program.Title
+	}
+	return out
+}
+
+func TestSearch(t *testing.T) {
+	db, err := NewMetaDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	all := db.Search(Query{})
+	if len(all) != len(db.programs) {
+		t.Errorf("empty query returned %d programs, want %d", len(all), len(db.programs))
+	}
+
+	res := db.Search(Query{Title: "blinky"})
+	if len(res) == 0 || res[0].Program.Title != "Blinky" {
+		t.Fatalf("title search = %v", titles(res))
+	}
+	for _, h := range res[0].Hashes {
+		if db.Program(h) != res[0].Program {
+			t.Errorf("hash %s does not belong to %s", h, res[0].Program.Title)
+		}
+	}
+
+	if res := db.Search(Query{Title: "blnky"}); len(res) != 0 {
+		t.Errorf("non-fuzzy search matched %v", titles(res))
+	}
+	if res := db.Search(Query{Title: "blnky", Fuzzy: true}); !slices.Contains(titles(res), "Blinky") {
+		t.Errorf("fuzzy search = %v, want Blinky", titles(res))
+	}
+
+	byAuthor := db.Search(Query{Author: "egeberg", Year: 1991})
+	if len(byAuthor) == 0 {
+		t.Error("no programs by Egeberg from 1991")
+	}
+	for _, r := range byAuthor {
+		if y, _ := releaseYear(r.Program.Release); y != 1991 {
+			t.Errorf("%s released %s, want 1991", r.Program.Title, r.Program.Release)
+		}
+	}
+
+	featured := db.Search(Query{Platform: "xochip", Features: FeatureColors | FeatureKeys})
+	if len(featured) == 0 {
+		t.Error("no XO-CHIP programs with colors and keys")
+	}
+	for _, r := range featured {
+		for _, h := range r.Hashes {
+			rom := r.Program.ROMs[h]
+			if !slices.Contains(rom.Platforms, "xochip") || rom.Colors == nil || len(rom.Keys) == 0 {
+				t.Errorf("%s rom %s does not match the platform and features", r.Program.Title, h)
+			}
+		}
+	}
+
+	if res := db.Search(Query{Limit: 3}); len(res) != 3 {
+		t.Errorf("Limit 3 returned %d", len(res))
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery("super tetris author:fran year:1991 platform:superchip has:colors,keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Query{Title: "super tetris", Fuzzy: true, Author: "fran", Year: 1991, Platform: "superchip", Features: FeatureColors | FeatureKeys}
+	if q != want {
+		t.Errorf("ParseQuery = %+v, want %+v", q, want)
+	}
+
+	for _, in := range []string{"year:19xx", "has:sound", "color:red"} {
+		if _, err := ParseQuery(in); err == nil {
+			t.Errorf("ParseQuery(%q) expected an error", in)
+		}
+	}
+}
+
+func TestHashByFile(t *testing.T) {
+	db, err := NewMetaDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, ok := db.HashByFile("SKYWARD.CH8")
+	if !ok || h != "8ebf74e790e58a8d5a7beff598bb32ed7eeeabf7" {
+		t.Errorf("HashByFile(SKYWARD.CH8) = %q, %t", h, ok)
+	}
+	if _, ok := db.HashByFile("missing.ch8"); ok {
+		t.Error("HashByFile(missing.ch8) should fail")
+	}
+}
@@ -0,0 +1,236 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+)
+
+// Source is a database directory on disk laid out like the embedded one:
+// programs.json, platforms.json and sha1-hashes.json.
+//
+// By default the directory is merged into the database loaded so far. It may
+// then hold only platforms.json, or only programs.json together with its
+// sha1-hashes.json. Platforms replace those with the same id and ROMs
+// replace those with the same hash; replacements that change an entry are
+// reported by MetaDB.Conflicts. With Replace set the directory must hold all
+// three files and the database loaded so far is dropped.
+type Source struct {
+	Dir     string
+	Replace bool
+}
+
+// Conflict is an entry of a Source that replaced a different one.
+type Conflict struct {
+	Dir  string
+	Kind string // "platform" or "rom"
+	Key  string // platform id or ROM hash
+	Old  string // platform name or program title before
+	New  string
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s: %s %s: %q replaced by %q", c.Dir, c.Kind, c.Key, c.Old, c.New)
+}
+
+func (db *MetaDB) load(src Source) error {
+	ext := MetaDB{}
+	if err := loadMeta(&ext, os.DirFS(src.Dir), !src.Replace); err != nil {
+		return err
+	}
+
+	if src.Replace {
+		if err := ext.validate(); err != nil {
+			return err
+		}
+		*db = ext
+		return nil
+	}
+
+	if (len(ext.programs) > 0) != (len(ext.hashes) > 0) {
+		return fmt.Errorf("%s and %s must be updated together", programsFile, hashesFile)
+	}
+
+	merged := db.clone()
+	merged.mergePlatforms(src.Dir, ext.platforms)
+	merged.mergePrograms(src.Dir, &ext)
+	merged.compact()
+
+	if err := merged.validate(); err != nil {
+		return err
+	}
+
+	*db = merged
+	return nil
+}
+
+// clone copies db deeply enough for merging not to modify it.
+func (db *MetaDB) clone() MetaDB {
+	c := MetaDB{
+		programs:  slices.Clone(db.programs),
+		platforms: slices.Clone(db.platforms),
+		hashes:    make(map[string]int, len(db.hashes)),
+		conflicts: slices.Clone(db.conflicts),
+	}
+	for i := range c.programs {
+		roms := make(map[string]ROMMeta, len(c.programs[i].ROMs))
+		for h, r := range c.programs[i].ROMs {
+			roms[h] = r
+		}
+		c.programs[i].ROMs = roms
+	}
+	for h, i := range db.hashes {
+		c.hashes[h] = i
+	}
+	return c
+}
+
+func (db *MetaDB) mergePlatforms(dir string, platforms []PlatformMeta) {
+	for _, p := range platforms {
+		i := slices.IndexFunc(db.platforms, func(old PlatformMeta) bool { return old.ID == p.ID })
+		if i < 0 {
+			db.platforms = append(db.platforms, p)
+			continue
+		}
+
+		if old := db.platforms[i]; !reflect.DeepEqual(old, p) {
+			db.conflicts = append(db.conflicts, Conflict{Dir: dir, Kind: "platform", Key: p.ID, Old: old.Name, New: p.Name})
+		}
+		db.platforms[i] = p
+	}
+}
+
+// mergePrograms appends the programs of ext and points their hashes at
+// them, removing each hash from the program that listed it before.
+func (db *MetaDB) mergePrograms(dir string, ext *MetaDB) {
+	offset := len(db.programs)
+	db.programs = append(db.programs, ext.programs...)
+
+	for _, h := range sortedKeys(ext.hashes) {
+		idx := ext.hashes[h] + offset
+		if idx < offset || idx >= len(db.programs) {
+			// Left for validate to report.
+			db.hashes[h] = idx
+			continue
+		}
+
+		if oldIdx, ok := db.hashes[h]; ok && oldIdx < offset {
+			old := &db.programs[oldIdx]
+			p := &db.programs[idx]
+			if old.Title != p.Title || !reflect.DeepEqual(old.ROMs[h], p.ROMs[h]) {
+				db.conflicts = append(db.conflicts, Conflict{Dir: dir, Kind: "rom", Key: h, Old: old.Title, New: p.Title})
+			}
+			delete(old.ROMs, h)
+		}
+		db.hashes[h] = idx
+	}
+}
+
+// compact drops programs left without ROMs by mergePrograms and renumbers
+// the hash index.
+func (db *MetaDB) compact() {
+	remap := make([]int, len(db.programs))
+	kept := db.programs[:0]
+
+	for i, p := range db.programs {
+		if len(p.ROMs) == 0 {
+			remap[i] = -1
+			continue
+		}
+		remap[i] = len(kept)
+		kept = append(kept, p)
+	}
+	db.programs = kept
+
+	for h, i := range db.hashes {
+		if i >= 0 && i < len(remap) {
+			db.hashes[h] = remap[i]
+		}
+	}
+}
+
+// validate checks required fields and that the hash index and the ROMs of
+// each program agree.
+func (db *MetaDB) validate() error {
+	var errs []error
+
+	seen := map[string]bool{}
+	for i, p := range db.platforms {
+		if p.ID == "" {
+			errs = append(errs, fmt.Errorf("%s: platform %d has no id", platformsFile, i))
+		} else if seen[p.ID] {
+			errs = append(errs, fmt.Errorf("%s: duplicate platform %q", platformsFile, p.ID))
+		}
+		seen[p.ID] = true
+	}
+
+	for i, p := range db.programs {
+		if p.Title == "" {
+			errs = append(errs, fmt.Errorf("%s: program %d has no title", programsFile, i))
+		}
+		if len(p.ROMs) == 0 {
+			errs = append(errs, fmt.Errorf("%s: program %d (%s) has no roms", programsFile, i, p.Title))
+		}
+
+		for _, h := range sortedKeys(p.ROMs) {
+			if !validHash(h) {
+				errs = append(errs, fmt.Errorf("%s: program %d (%s): invalid hash %q", programsFile, i, p.Title, h))
+			}
+			if idx, ok := db.hashes[h]; !ok || idx != i {
+				errs = append(errs, fmt.Errorf("%s: rom %s of program %d (%s) is not indexed to it", hashesFile, h, i, p.Title))
+			}
+			rom := p.ROMs[h]
+			for _, id := range rom.PlatformIDs() {
+				if !seen[id] {
+					errs = append(errs, fmt.Errorf("%s: rom %s: unknown platform %q", programsFile, h, id))
+				}
+			}
+			for _, id := range sortedKeys(rom.QuirkyPlatforms) {
+				for _, name := range sortedKeys(rom.QuirkyPlatforms[id]) {
+					if !slices.Contains(QuirkNames, name) {
+						errs = append(errs, fmt.Errorf("%s: rom %s: unknown quirk %q", programsFile, h, name))
+					}
+				}
+			}
+			switch rom.ScreenRotation {
+			case 0, 90, 180, 270:
+			default:
+				errs = append(errs, fmt.Errorf("%s: rom %s: invalid screen rotation %d", programsFile, h, rom.ScreenRotation))
+			}
+		}
+	}
+
+	for _, h := range sortedKeys(db.hashes) {
+		idx := db.hashes[h]
+		if idx < 0 || idx >= len(db.programs) {
+			errs = append(errs, fmt.Errorf("%s: %s points to missing program %d", hashesFile, h, idx))
+		} else if _, ok := db.programs[idx].ROMs[h]; !ok {
+			errs = append(errs, fmt.Errorf("%s: %s points to program %d (%s) without that rom", hashesFile, h, idx, db.programs[idx].Title))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func validHash(h string) bool {
+	if len(h) != 40 {
+		return false
+	}
+	for _, c := range h {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	hashA = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	hashB = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+)
+
+func writeDB(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestEmbeddedDBValid(t *testing.T) {
+	db, err := NewMetaDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMergeSource(t *testing.T) {
+	base, err := NewMetaDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Take over an embedded ROM under a new title and add a new one.
+	var known string
+	for h := range base.hashes {
+		known = h
+		break
+	}
+	oldTitle := base.Program(known).Title
+
+	dir := writeDB(t, map[string]string{
+		programsFile: `[
+			{"title": "Renamed", "roms": {"` + known + `": {"file": "a.ch8", "platforms": ["originalChip8"]}}},
+			{"title": "New Game", "roms": {"` + hashA + `": {"file": "b.ch8", "platforms": ["newPlatform"]}}}
+		]`,
+		hashesFile:    `{"` + known + `": 0, "` + hashA + `": 1}`,
+		platformsFile: `[{"id": "newPlatform", "name": "New"}]`,
+	})
+
+	db, err := NewMetaDB(Source{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p := db.Program(known); p == nil || p.Title != "Renamed" {
+		t.Errorf("Program(known) = %+v, want the merged one", p)
+	}
+	if p := db.Program(hashA); p == nil || p.Title != "New Game" {
+		t.Errorf("Program(new) = %+v", p)
+	}
+	if db.Platform("newPlatform") == nil || db.Platform("originalChip8") == nil {
+		t.Error("platforms not merged")
+	}
+	if err := db.validate(); err != nil {
+		t.Errorf("merged database invalid: %v", err)
+	}
+
+	conflicts := db.Conflicts()
+	if len(conflicts) != 1 || conflicts[0].Key != known || conflicts[0].Old != oldTitle || conflicts[0].New != "Renamed" {
+		t.Errorf("Conflicts() = %v", conflicts)
+	}
+}
+
+func TestReplaceSource(t *testing.T) {
+	dir := writeDB(t, map[string]string{
+		programsFile:  `[{"title": "Only", "roms": {"` + hashA + `": {"file": "a.ch8", "platforms": ["p"]}}}]`,
+		hashesFile:    `{"` + hashA + `": 0}`,
+		platformsFile: `[{"id": "p"}]`,
+	})
+
+	db, err := NewMetaDB(Source{Dir: dir, Replace: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(db.programs) != 1 || len(db.platforms) != 1 || db.Platform("originalChip8") != nil {
+		t.Errorf("replaced database = %d programs, %d platforms", len(db.programs), len(db.platforms))
+	}
+
+	// A replacement must be complete.
+	partial := writeDB(t, map[string]string{platformsFile: `[]`})
+	if _, err := NewMetaDB(Source{Dir: partial, Replace: true}); err == nil {
+		t.Error("partial replacement should fail")
+	}
+}
+
+func TestInvalidSource(t *testing.T) {
+	tests := map[string]map[string]string{
+		"syntax": {platformsFile: `[{"id": }]`},
+		"programs without hashes": {
+			programsFile: `[{"title": "X", "roms": {"` + hashA + `": {}}}]`,
+		},
+		"index points elsewhere": {
+			programsFile: `[{"title": "X", "roms": {"` + hashA + `": {}}}]`,
+			hashesFile:   `{"` + hashA + `": 0, "` + hashB + `": 0}`,
+		},
+		"index out of range": {
+			programsFile: `[{"title": "X", "roms": {"` + hashA + `": {}}}]`,
+			hashesFile:   `{"` + hashA + `": 3}`,
+		},
+		"unknown platform": {
+			programsFile: `[{"title": "X", "roms": {"` + hashA + `": {"platforms": ["nope"]}}}]`,
+			hashesFile:   `{"` + hashA + `": 0}`,
+		},
+		"unknown quirky platform": {
+			programsFile: `[{"title": "X", "roms": {"` + hashA + `": {"quirkyPlatforms": {"nope": {}}}}}]`,
+			hashesFile:   `{"` + hashA + `": 0}`,
+		},
+		"unknown quirk": {
+			programsFile: `[{"title": "X", "roms": {"` + hashA + `": {"quirkyPlatforms": {"superchip": {"turbo": true}}}}}]`,
+			hashesFile:   `{"` + hashA + `": 0}`,
+		},
+		"bad rotation": {
+			programsFile: `[{"title": "X", "roms": {"` + hashA + `": {"screenRotation": 45}}}]`,
+			hashesFile:   `{"` + hashA + `": 0}`,
+		},
+		"no title": {
+			programsFile: `[{"roms": {"` + hashA + `": {}}}]`,
+			hashesFile:   `{"` + hashA + `": 0}`,
+		},
+		"bad hash": {
+			programsFile: `[{"title": "X", "roms": {"xyz": {}}}]`,
+			hashesFile:   `{"xyz": 0}`,
+		},
+	}
+
+	for name, files := range tests {
+		dir := writeDB(t, files)
+		_, err := NewMetaDB(Source{Dir: dir})
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+			continue
+		}
+		if !strings.Contains(err.Error(), dir) {
+			t.Errorf("%s: error %q should name the directory", name, err)
+		}
+	}
+}
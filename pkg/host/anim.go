@@ -0,0 +1,343 @@
+package host
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const DefaultRecordScale = 4
+
+// AnimFormat selects the container written by FrameRecorder.
+type AnimFormat int
+
+const (
+	AnimGIF AnimFormat = iota
+	AnimAPNG
+)
+
+var AnimFormatByExt = map[string]AnimFormat{
+	".gif":  AnimGIF,
+	".png":  AnimAPNG,
+	".apng": AnimAPNG,
+}
+
+type animFrame struct {
+	pix   []byte // palette indices, unscaled
+	delay time.Duration
+}
+
+// FrameRecorder captures FrameBuffer frames into an animated GIF or APNG.
+//
+// Frames are quantised to an indexed palette seeded with the 16 XO-CHIP
+// colors of the active Palette, and identical consecutive frames are merged
+// by extending the previous frame's delay.
+type FrameRecorder struct {
+	Scale   int
+	width   int
+	height  int
+	colors  []Color
+	indices map[Color]byte
+	frames  []animFrame
+	last    []byte // RGBA pixels of the last added frame
+}
+
+func NewFrameRecorder(scale int) *FrameRecorder {
+	return &FrameRecorder{
+		Scale:   max(scale, 1),
+		indices: map[Color]byte{},
+	}
+}
+
+// Len returns the number of distinct frames recorded.
+func (r *FrameRecorder) Len() int {
+	return len(r.frames)
+}
+
+func (r *FrameRecorder) Duration() (d time.Duration) {
+	for _, f := range r.frames {
+		d += f.delay
+	}
+	return d
+}
+
+// Add appends fb shown for delay. Frames identical to the previous one only
+// extend its delay.
+func (r *FrameRecorder) Add(fb *FrameBuffer, pal *Palette, delay time.Duration) {
+	if n := len(r.frames); n > 0 {
+		// A resolution change mid-recording cannot be represented, so such
+		// frames are treated like repeats.
+		if fb.Width != r.width || fb.Height != r.height || bytes.Equal(fb.Pixels, r.last) {
+			r.frames[n-1].delay += delay
+			return
+		}
+	} else {
+		r.width = fb.Width
+		r.height = fb.Height
+		for _, c := range pal.Pixels {
+			r.index(c)
+		}
+	}
+
+	pix := make([]byte, r.width*r.height)
+	for i := range pix {
+		var c Color
+		copy(c[:], fb.Pixels[i*fb.BPP:])
+		pix[i] = r.index(c)
+	}
+
+	if n := len(r.frames); n > 0 && bytes.Equal(pix, r.frames[n-1].pix) {
+		r.frames[n-1].delay += delay
+	} else {
+		r.frames = append(r.frames, animFrame{pix: pix, delay: delay})
+	}
+
+	r.last = append(r.last[:0], fb.Pixels...)
+}
+
+// index returns the palette slot for c, adding it while there is room and
+// falling back to the nearest known color otherwise.
+func (r *FrameRecorder) index(c Color) byte {
+	if i, ok := r.indices[c]; ok {
+		return i
+	}
+
+	if len(r.colors) < 256 {
+		i := byte(len(r.colors))
+		r.colors = append(r.colors, c)
+		r.indices[c] = i
+		return i
+	}
+
+	best, bestDist := 0, math.MaxInt
+	for i, p := range r.colors {
+		dr, dg, db := int(c[0])-int(p[0]), int(c[1])-int(p[1]), int(c[2])-int(p[2])
+		if dist := dr*dr + dg*dg + db*db; dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+
+	return byte(best)
+}
+
+func (r *FrameRecorder) colorPalette() color.Palette {
+	p := make(color.Palette, len(r.colors))
+	for i, c := range r.colors {
+		p[i] = color.RGBA{c[0], c[1], c[2], c[3]}
+	}
+	return p
+}
+
+func (r *FrameRecorder) image(f *animFrame, pal color.Palette) *image.Paletted {
+	s := r.Scale
+	img := image.NewPaletted(image.Rect(0, 0, r.width*s, r.height*s), pal)
+
+	for y := range r.height {
+		src := f.pix[y*r.width : (y+1)*r.width]
+		row := img.Pix[y*s*img.Stride : y*s*img.Stride+r.width*s]
+
+		for x, v := range src {
+			for i := range s {
+				row[x*s+i] = v
+			}
+		}
+
+		for i := 1; i < s; i++ {
+			copy(img.Pix[(y*s+i)*img.Stride:], row)
+		}
+	}
+
+	return img
+}
+
+// delays converts frame durations into integer ticks of unit, rounding the
+// cumulative time so rounding errors do not drift over long recordings.
+func (r *FrameRecorder) delays(unit time.Duration) []int {
+	out := make([]int, len(r.frames))
+	var elapsed time.Duration
+	prev := 0
+
+	for i, f := range r.frames {
+		elapsed += f.delay
+		end := int((elapsed + unit/2) / unit)
+		out[i] = max(end-prev, 1)
+		prev = end
+	}
+
+	return out
+}
+
+// GIF encodes the recording as a looping animated GIF.
+func (r *FrameRecorder) GIF() ([]byte, error) {
+	if len(r.frames) == 0 {
+		return nil, fmt.Errorf("no frames recorded")
+	}
+
+	pal := r.colorPalette()
+	anim := gif.GIF{}
+
+	for i, d := range r.delays(10 * time.Millisecond) {
+		anim.Image = append(anim.Image, r.image(&r.frames[i], pal))
+		anim.Delay = append(anim.Delay, d)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, &anim); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// APNG encodes the recording as a looping animated PNG.
+//
+// Every frame is encoded with image/png against the same palette, so they
+// share IHDR/PLTE and only their IDAT payloads are rewrapped as APNG frames.
+func (r *FrameRecorder) APNG() ([]byte, error) {
+	if len(r.frames) == 0 {
+		return nil, fmt.Errorf("no frames recorded")
+	}
+
+	pal := r.colorPalette()
+	delays := r.delays(time.Millisecond)
+	w := apngWriter{}
+	w.buf.WriteString(pngSignature)
+
+	for i := range r.frames {
+		var enc bytes.Buffer
+		if err := png.Encode(&enc, r.image(&r.frames[i], pal)); err != nil {
+			return nil, err
+		}
+
+		chunks, err := pngChunks(enc.Bytes())
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			for _, c := range chunks {
+				if c.typ == "IHDR" || c.typ == "PLTE" || c.typ == "tRNS" {
+					w.chunk(c.typ, c.data)
+				}
+			}
+			actl := make([]byte, 8)
+			binary.BigEndian.PutUint32(actl[0:], uint32(len(r.frames)))
+			binary.BigEndian.PutUint32(actl[4:], 0) // loop forever
+			w.chunk("acTL", actl)
+		}
+
+		w.frameControl(r.width*r.Scale, r.height*r.Scale, delays[i])
+
+		for _, c := range chunks {
+			if c.typ != "IDAT" {
+				continue
+			}
+			if i == 0 {
+				w.chunk("IDAT", c.data)
+			} else {
+				w.chunk("fdAT", append(w.seqBytes(), c.data...))
+			}
+		}
+	}
+
+	w.chunk("IEND", nil)
+	return w.buf.Bytes(), nil
+}
+
+// Save writes the recording in the format chosen by the file extension.
+func (r *FrameRecorder) Save(path string) error {
+	format, ok := AnimFormatByExt[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return fmt.Errorf("unsupported animation format: %q", filepath.Ext(path))
+	}
+
+	var data []byte
+	var err error
+
+	switch format {
+	case AnimGIF:
+		data, err = r.GIF()
+	case AnimAPNG:
+		data, err = r.APNG()
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+func pngChunks(data []byte) ([]pngChunk, error) {
+	if !bytes.HasPrefix(data, []byte(pngSignature)) {
+		return nil, fmt.Errorf("not a PNG")
+	}
+
+	var chunks []pngChunk
+	for p := len(pngSignature); p+12 <= len(data); {
+		n := int(binary.BigEndian.Uint32(data[p:]))
+		if p+12+n > len(data) {
+			return nil, fmt.Errorf("truncated PNG chunk")
+		}
+		chunks = append(chunks, pngChunk{typ: string(data[p+4 : p+8]), data: data[p+8 : p+8+n]})
+		p += 12 + n
+	}
+
+	return chunks, nil
+}
+
+type apngWriter struct {
+	buf bytes.Buffer
+	seq uint32
+}
+
+func (w *apngWriter) chunk(typ string, data []byte) {
+	var hdr [8]byte
+	binary.BigEndian.PutUint32(hdr[:], uint32(len(data)))
+	copy(hdr[4:], typ)
+	w.buf.Write(hdr[:])
+	w.buf.Write(data)
+
+	crc := crc32.NewIEEE()
+	crc.Write(hdr[4:])
+	crc.Write(data)
+	binary.Write(&w.buf, binary.BigEndian, crc.Sum32())
+}
+
+// seqBytes returns the next APNG sequence number shared by fcTL and fdAT.
+func (w *apngWriter) seqBytes() []byte {
+	b := binary.BigEndian.AppendUint32(nil, w.seq)
+	w.seq++
+	return b
+}
+
+func (w *apngWriter) frameControl(width, height, delayMs int) {
+	fctl := w.seqBytes()
+	fctl = binary.BigEndian.AppendUint32(fctl, uint32(width))
+	fctl = binary.BigEndian.AppendUint32(fctl, uint32(height))
+	fctl = binary.BigEndian.AppendUint32(fctl, 0) // x offset
+	fctl = binary.BigEndian.AppendUint32(fctl, 0) // y offset
+	fctl = binary.BigEndian.AppendUint16(fctl, uint16(min(delayMs, math.MaxUint16)))
+	fctl = binary.BigEndian.AppendUint16(fctl, 1000)
+	fctl = append(fctl, 0, 0) // dispose none, blend source
+	w.chunk("fcTL", fctl)
+}
@@ -0,0 +1,180 @@
+package host
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testFrames() (a, b FrameBuffer) {
+	a = newFrameBuffer(2, 2, 4)
+	b = newFrameBuffer(2, 2, 4)
+	for i := 0; i < len(a.Pixels); i += 4 {
+		copy(a.Pixels[i:], DefaultPalette.Pixels[0][:])
+		copy(b.Pixels[i:], DefaultPalette.Pixels[1][:])
+	}
+	return a, b
+}
+
+func TestFrameRecorderDedup(t *testing.T) {
+	a, b := testFrames()
+	r := NewFrameRecorder(3)
+	pal := DefaultPalette
+
+	r.Add(&a, &pal, 10*time.Millisecond)
+	r.Add(&a, &pal, 10*time.Millisecond) // duplicate extends the first frame
+	r.Add(&b, &pal, 10*time.Millisecond)
+
+	if r.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", r.Len())
+	}
+	if d := r.Duration(); d != 30*time.Millisecond {
+		t.Errorf("Duration() = %v, want 30ms", d)
+	}
+	if len(r.colors) != len(pal.Pixels) {
+		t.Errorf("palette size = %d, want the %d seeded colors", len(r.colors), len(pal.Pixels))
+	}
+	if r.frames[0].pix[0] != 0 || r.frames[1].pix[0] != 1 {
+		t.Errorf("palette indices = %d, %d, want 0, 1", r.frames[0].pix[0], r.frames[1].pix[0])
+	}
+}
+
+func TestFrameRecorderGIF(t *testing.T) {
+	a, b := testFrames()
+	r := NewFrameRecorder(3)
+	pal := DefaultPalette
+
+	if _, err := r.GIF(); err == nil {
+		t.Error("GIF() with no frames should return an error")
+	}
+
+	for range 3 {
+		r.Add(&a, &pal, FrameDelta)
+	}
+	r.Add(&b, &pal, FrameDelta)
+
+	data, err := r.GIF()
+	if err != nil {
+		t.Fatalf("GIF() error = %v", err)
+	}
+
+	anim, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecodeAll error = %v", err)
+	}
+	if len(anim.Image) != 2 {
+		t.Fatalf("frames = %d, want 2", len(anim.Image))
+	}
+	if got := anim.Image[0].Bounds().Dx(); got != 6 {
+		t.Errorf("scaled width = %d, want 6", got)
+	}
+	// 3/60 s = 5cs, then 4/60 s rounds to 7cs in total.
+	if anim.Delay[0] != 5 || anim.Delay[1] != 2 {
+		t.Errorf("delays = %v, want [5 2]", anim.Delay)
+	}
+	if c := anim.Image[1].At(5, 5); c != anim.Image[1].Palette[1] {
+		t.Errorf("pixel color = %v, want palette entry 1", c)
+	}
+}
+
+func TestFrameRecorderAPNG(t *testing.T) {
+	a, b := testFrames()
+	r := NewFrameRecorder(2)
+	pal := DefaultPalette
+	r.Add(&a, &pal, FrameDelta)
+	r.Add(&b, &pal, FrameDelta)
+
+	data, err := r.APNG()
+	if err != nil {
+		t.Fatalf("APNG() error = %v", err)
+	}
+
+	// The default image is the first frame, readable by any PNG decoder.
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("png.Decode error = %v", err)
+	}
+	if img.Bounds().Dx() != 4 || img.Bounds().Dy() != 4 {
+		t.Errorf("image size = %v, want 4x4", img.Bounds())
+	}
+
+	chunks, err := pngChunks(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[string]int{}
+	var seq []uint32
+	for _, c := range chunks {
+		counts[c.typ]++
+		switch c.typ {
+		case "acTL":
+			if n := binary.BigEndian.Uint32(c.data); n != 2 {
+				t.Errorf("acTL frames = %d, want 2", n)
+			}
+		case "fcTL", "fdAT":
+			seq = append(seq, binary.BigEndian.Uint32(c.data))
+		}
+	}
+
+	if counts["acTL"] != 1 || counts["fcTL"] != 2 || counts["fdAT"] == 0 || counts["IEND"] != 1 {
+		t.Errorf("chunk counts = %v", counts)
+	}
+	for i, s := range seq {
+		if s != uint32(i) {
+			t.Fatalf("sequence numbers = %v, want 0..%d", seq, len(seq)-1)
+		}
+	}
+}
+
+func TestFrameRecorderSave(t *testing.T) {
+	a, _ := testFrames()
+	r := NewFrameRecorder(1)
+	pal := DefaultPalette
+	r.Add(&a, &pal, FrameDelta)
+
+	dir := t.TempDir()
+	for _, name := range []string{"out.gif", "out.png"} {
+		path := filepath.Join(dir, name)
+		if err := r.Save(path); err != nil {
+			t.Fatalf("Save(%s) error = %v", name, err)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected file at %s: %v", path, err)
+		}
+	}
+
+	if err := r.Save(filepath.Join(dir, "out.bmp")); err == nil {
+		t.Error("Save with an unknown extension should return an error")
+	}
+}
+
+func TestEmuVideoRecording(t *testing.T) {
+	emu := setup(t, "../../testdata/roms/test/timendus/2-ibm-logo.ch8")
+
+	if emu.StopVideoRecording() != nil {
+		t.Error("StopVideoRecording() without a recording should return nil")
+	}
+
+	rec := emu.StartVideoRecording(1)
+	if !emu.VideoRecording() {
+		t.Fatal("VideoRecording() = false after StartVideoRecording")
+	}
+
+	emu.RunFrames(60)
+
+	if got := emu.StopVideoRecording(); got != rec {
+		t.Fatal("StopVideoRecording() should return the active recorder")
+	}
+	if rec.Len() == 0 || rec.Len() >= 60 {
+		t.Errorf("Len() = %d, want a few unique frames out of 60", rec.Len())
+	}
+	if d := rec.Duration(); d != 60*FrameDelta {
+		t.Errorf("Duration() = %v, want %v", d, 60*FrameDelta)
+	}
+}
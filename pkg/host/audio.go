@@ -0,0 +1,170 @@
+package host
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+)
+
+const (
+	DefaultSampleRate = 44100
+	DefaultVolume     = 0.5
+	VolumeStep        = 0.1
+	// audioBufferTime bounds how far the producer may run ahead of the audio
+	// device before old samples are dropped.
+	audioBufferTime = time.Second / 10
+)
+
+// AudioStream is a mono float32 PCM stream fed by the emulator.
+//
+// Samples are rendered from chip8.Audio on the emulation goroutine once per
+// frame and drained by the host audio backend (SDL2 callback, Ebiten player)
+// on its own goroutine. Underruns are padded with silence and overruns drop
+// the oldest samples so latency stays bounded.
+type AudioStream struct {
+	SampleRate int
+	mu         sync.Mutex
+	ring       []float32
+	head       int // index of the oldest buffered sample
+	size       int // number of buffered samples
+	frame      []float32
+	carry      int64 // sample-rate-scaled nanoseconds not yet rendered
+	volume     float32
+	muted      bool
+	recorder   *AudioRecorder
+}
+
+func NewAudioStream(sampleRate int) *AudioStream {
+	capacity := int(float64(sampleRate) * audioBufferTime.Seconds())
+
+	return &AudioStream{
+		SampleRate: sampleRate,
+		ring:       make([]float32, capacity),
+		volume:     DefaultVolume,
+	}
+}
+
+// Render generates the samples covering frameDelta and appends them to the
+// stream. The returned slice is only valid until the next call.
+func (s *AudioStream) Render(audio *chip8.Audio, frameDelta time.Duration) []float32 {
+	// Integer accounting keeps the sample count exact across frames.
+	s.carry += int64(frameDelta) * int64(s.SampleRate)
+	n := int(s.carry / int64(time.Second))
+	s.carry -= int64(n) * int64(time.Second)
+
+	if cap(s.frame) < n {
+		s.frame = make([]float32, n)
+	}
+	s.frame = s.frame[:n]
+	audio.Output(s.frame, float64(s.SampleRate))
+
+	if s.recorder != nil {
+		s.recorder.Write(s.frame)
+	}
+
+	s.mu.Lock()
+	s.write(s.frame)
+	s.mu.Unlock()
+
+	return s.frame
+}
+
+func (s *AudioStream) write(samples []float32) {
+	capacity := len(s.ring)
+	if capacity == 0 {
+		return
+	}
+
+	if len(samples) > capacity {
+		samples = samples[len(samples)-capacity:]
+	}
+
+	if overflow := s.size + len(samples) - capacity; overflow > 0 {
+		s.head = (s.head + overflow) % capacity
+		s.size -= overflow
+	}
+
+	tail := (s.head + s.size) % capacity
+	for _, v := range samples {
+		s.ring[tail] = v
+		tail = (tail + 1) % capacity
+	}
+	s.size += len(samples)
+}
+
+// Read fills out with buffered samples scaled by the current volume and pads
+// the remainder with silence. It always fills the whole slice.
+func (s *AudioStream) Read(out []float32) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	gain := s.volume
+	if s.muted {
+		gain = 0
+	}
+
+	n := min(len(out), s.size)
+	for i := range n {
+		out[i] = s.ring[s.head] * gain
+		s.head = (s.head + 1) % len(s.ring)
+	}
+	s.size -= n
+	clear(out[n:])
+
+	return len(out)
+}
+
+// Buffered returns the number of samples waiting to be read.
+func (s *AudioStream) Buffered() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.size
+}
+
+func (s *AudioStream) Volume() float32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.volume
+}
+
+// SetVolume sets the output gain, clamped to 0..1.
+func (s *AudioStream) SetVolume(v float32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.volume = min(max(v, 0), 1)
+}
+
+// AdjustVolume changes the volume by delta and returns the new value.
+func (s *AudioStream) AdjustVolume(delta float32) float32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.volume = min(max(s.volume+delta, 0), 1)
+	return s.volume
+}
+
+func (s *AudioStream) Muted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.muted
+}
+
+func (s *AudioStream) SetMuted(muted bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.muted = muted
+}
+
+func (s *AudioStream) ToggleMute() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.muted = !s.muted
+	return s.muted
+}
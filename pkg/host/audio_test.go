@@ -0,0 +1,122 @@
+package host
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+)
+
+func TestAudioStreamRenderAndRead(t *testing.T) {
+	s := NewAudioStream(6000)
+	a := chip8.NewAudio()
+
+	// 6000 Hz * 1/60 s is just under 100 samples; the remainder carries over.
+	if got := len(s.Render(&a, time.Second/60)); got != 99 {
+		t.Errorf("Render() samples = %d, want 99", got)
+	}
+	if got := len(s.Render(&a, time.Second/60)); got != 100 {
+		t.Errorf("Render() second frame samples = %d, want 100", got)
+	}
+	if got := s.Buffered(); got != 199 {
+		t.Errorf("Buffered() = %d, want 199", got)
+	}
+
+	out := make([]float32, 250)
+	for i := range out {
+		out[i] = 0.5
+	}
+	if n := s.Read(out); n != len(out) {
+		t.Errorf("Read() = %d, want %d", n, len(out))
+	}
+	for i, v := range out {
+		if v != 0 {
+			t.Fatalf("silent audio / underrun: out[%d] = %v, want 0", i, v)
+		}
+	}
+	if got := s.Buffered(); got != 0 {
+		t.Errorf("Buffered() after drain = %d, want 0", got)
+	}
+}
+
+func TestAudioStreamVolumeAndMute(t *testing.T) {
+	s := NewAudioStream(6000)
+	s.write([]float32{1, -1})
+	s.SetVolume(0.25)
+
+	out := make([]float32, 2)
+	s.Read(out)
+	if out[0] != 0.25 || out[1] != -0.25 {
+		t.Errorf("Read() with volume 0.25 = %v, want [0.25 -0.25]", out)
+	}
+
+	s.write([]float32{1})
+	if !s.ToggleMute() {
+		t.Fatal("ToggleMute() should report muted")
+	}
+	s.Read(out[:1])
+	if out[0] != 0 {
+		t.Errorf("muted sample = %v, want 0", out[0])
+	}
+
+	if v := s.AdjustVolume(2); v != 1 {
+		t.Errorf("AdjustVolume clamps to 1, got %v", v)
+	}
+	if v := s.AdjustVolume(-5); v != 0 {
+		t.Errorf("AdjustVolume clamps to 0, got %v", v)
+	}
+}
+
+func TestAudioStreamOverrunDropsOldest(t *testing.T) {
+	s := NewAudioStream(100) // 10 samples of buffering
+	s.SetVolume(1)
+
+	in := make([]float32, 15)
+	for i := range in {
+		in[i] = float32(i)
+	}
+	s.write(in[:8])
+	s.write(in[8:])
+
+	if got := s.Buffered(); got != 10 {
+		t.Fatalf("Buffered() = %d, want 10", got)
+	}
+
+	out := make([]float32, 10)
+	s.Read(out)
+	if out[0] != 5 || out[9] != 14 {
+		t.Errorf("Read() = %v, want the newest samples 5..14", out)
+	}
+}
+
+func TestEmuRendersAudio(t *testing.T) {
+	emu, _ := NewEmu()
+	stream := emu.EnableAudio(6000)
+
+	// LD V0, 0A ; LD ST, V0 ; JP 0204
+	rom := []byte{0x60, 0x0A, 0xF0, 0x18, 0x12, 0x04}
+	if _, err := emu.LoadROM(rom, ".ch8"); err != nil {
+		t.Fatal(err)
+	}
+
+	emu.runFrame(FrameDelta) // sound timer is set during this frame
+	first := make([]float32, stream.Buffered())
+	stream.Read(first)
+	for i, v := range first {
+		if v != 0 {
+			t.Fatalf("first frame: sample %d = %v, want silence", i, v)
+		}
+	}
+
+	emu.runFrame(FrameDelta)
+	second := make([]float32, stream.Buffered())
+	stream.Read(second)
+
+	var audible bool
+	for _, v := range second {
+		audible = audible || v != 0
+	}
+	if len(second) == 0 || !audible {
+		t.Error("second frame should contain the beep")
+	}
+}
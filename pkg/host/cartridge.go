@@ -0,0 +1,95 @@
+package host
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/gif"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrCartridgeSource is returned for cartridges whose program is Octo
+// source rather than a byte listing.
+var ErrCartridgeSource = errors.New("cartridge program needs the Octo assembler")
+
+// Cartridge is an Octo cartridge: a GIF whose frames carry an Octo project.
+// The low four bits of consecutive pixels, across all frames, hold a
+// big-endian 32-bit length followed by that many bytes of JSON.
+type Cartridge struct {
+	Key     string      `json:"key,omitempty"`
+	Created string      `json:"created,omitempty"`
+	Program string      `json:"program"` // Octo source code
+	Options OctoOptions `json:"options"`
+}
+
+// ParseCartridge decodes the Octo cartridge in the GIF read from r.
+func ParseCartridge(r io.Reader) (*Cartridge, error) {
+	g, err := gif.DecodeAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	var hi byte
+	odd := false
+	for _, frame := range g.Image {
+		for _, px := range frame.Pix {
+			if odd {
+				data = append(data, hi<<4|px&0x0F)
+			} else {
+				hi = px & 0x0F
+			}
+			odd = !odd
+		}
+	}
+
+	if len(data) < 4 {
+		return nil, errors.New("not an Octo cartridge: image too small")
+	}
+	n := int(data[0])<<24 | int(data[1])<<16 | int(data[2])<<8 | int(data[3])
+	if n > len(data)-4 {
+		return nil, errors.New("not an Octo cartridge: payload length out of range")
+	}
+
+	var c Cartridge
+	if err := json.Unmarshal(data[4:4+n], &c); err != nil {
+		return nil, fmt.Errorf("not an Octo cartridge: %w", err)
+	}
+	if err := c.Options.Validate(); err != nil {
+		return nil, fmt.Errorf("cartridge options: %w", err)
+	}
+	return &c, nil
+}
+
+// ROM returns the program bytes when the source is a plain byte listing,
+// such as Octo produces when it opens a binary ROM: byte literals, an
+// optional ": main" label and comments. Other programs need the Octo
+// assembler.
+func (c *Cartridge) ROM() ([]byte, error) {
+	var rom []byte
+	for i, line := range strings.Split(c.Program, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		fields := strings.Fields(line)
+
+		for j := 0; j < len(fields); j++ {
+			tok := fields[j]
+			if tok == ":" && j+1 < len(fields) && fields[j+1] == "main" && len(rom) == 0 {
+				j++
+				continue
+			}
+
+			v, err := strconv.ParseInt(tok, 0, 16)
+			if err != nil || v < -128 || v > 255 {
+				return nil, fmt.Errorf("line %d: %q: %w", i+1, tok, ErrCartridgeSource)
+			}
+			rom = append(rom, byte(v))
+		}
+	}
+
+	if len(rom) == 0 {
+		return nil, errors.New("cartridge program is empty")
+	}
+	return rom, nil
+}
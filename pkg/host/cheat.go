@@ -0,0 +1,422 @@
+package host
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+)
+
+// CheatTarget addresses one byte of emulator state: a memory address or,
+// when Reg is set, the V register with index Addr.
+type CheatTarget struct {
+	Reg  bool
+	Addr uint16
+}
+
+// ParseCheatTarget accepts "v0"-"vF" or a memory address in hex ("0x300")
+// or decimal ("768").
+func ParseCheatTarget(s string) (CheatTarget, error) {
+	if len(s) >= 2 && (s[0] == 'v' || s[0] == 'V') {
+		n, err := strconv.ParseUint(s[1:], 16, 8)
+		if err != nil || n > 0xF {
+			return CheatTarget{}, fmt.Errorf("invalid register %q (use v0-vF)", s)
+		}
+		return CheatTarget{Reg: true, Addr: uint16(n)}, nil
+	}
+
+	addr, err := strconv.ParseUint(s, 0, 16)
+	if err != nil {
+		return CheatTarget{}, fmt.Errorf("invalid target %q (use <addr> or v<x>)", s)
+	}
+	return CheatTarget{Addr: uint16(addr)}, nil
+}
+
+func (t CheatTarget) String() string {
+	if t.Reg {
+		return fmt.Sprintf("V%X", t.Addr)
+	}
+	return fmt.Sprintf("0x%04X", t.Addr)
+}
+
+func (t CheatTarget) Read(vm *chip8.VM) byte {
+	if t.Reg {
+		return vm.CPU.V(byte(t.Addr))
+	}
+	return vm.Memory.Read(t.Addr)
+}
+
+func (t CheatTarget) Write(vm *chip8.VM, val byte) {
+	if t.Reg {
+		vm.CPU.SetV(byte(t.Addr), val)
+	} else {
+		vm.Memory.Write(t.Addr, val)
+	}
+}
+
+// ParseCheatValue accepts a byte in hex ("0x0A") or decimal ("10").
+func ParseCheatValue(s string) (byte, error) {
+	v, err := strconv.ParseUint(s, 0, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q (use 0-255 or 0x00-0xFF)", s)
+	}
+	return byte(v), nil
+}
+
+// CheatCond limits a cheat to frames where the current value of its target
+// compares true against Value, e.g. "<9" refills lives only once one is lost.
+// The zero value always matches.
+type CheatCond struct {
+	Op    string // "", "==", "!=", "<", "<=", ">" or ">="
+	Value byte
+}
+
+var cheatCondOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// ParseCheatCond parses an operator followed by a value, such as "<9" or
+// "!=0x00".
+func ParseCheatCond(s string) (CheatCond, error) {
+	for _, op := range cheatCondOps {
+		if rest, ok := strings.CutPrefix(s, op); ok {
+			v, err := ParseCheatValue(rest)
+			if err != nil {
+				return CheatCond{}, err
+			}
+			return CheatCond{Op: op, Value: v}, nil
+		}
+	}
+	return CheatCond{}, fmt.Errorf("invalid condition %q (use ==, !=, <, <=, > or >= and a value)", s)
+}
+
+func (c CheatCond) String() string {
+	if c.Op == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s0x%02X", c.Op, c.Value)
+}
+
+// Match reports whether cur satisfies the condition.
+func (c CheatCond) Match(cur byte) bool {
+	switch c.Op {
+	case "==":
+		return cur == c.Value
+	case "!=":
+		return cur != c.Value
+	case "<":
+		return cur < c.Value
+	case "<=":
+		return cur <= c.Value
+	case ">":
+		return cur > c.Value
+	case ">=":
+		return cur >= c.Value
+	}
+	return true
+}
+
+// Cheat keeps a target at a fixed value. Enabled cheats are rewritten after
+// every frame in which their condition matches.
+type Cheat struct {
+	Target      CheatTarget
+	Value       byte
+	Cond        CheatCond
+	Enabled     bool
+	Description string
+}
+
+// ParseCheats reads a cheat file. Each line holds one cheat:
+//
+//	[off] <target> <value> [<condition>] [description]
+//
+// for example "v3 9 <9 Infinite lives" or "off 0x2F0 0 Skip intro". Blank
+// lines and lines starting with '#' are ignored.
+func ParseCheats(r io.Reader) ([]Cheat, error) {
+	var cheats []Cheat
+	sc := bufio.NewScanner(r)
+
+	for n := 1; sc.Scan(); n++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		c := Cheat{Enabled: true}
+		if strings.EqualFold(fields[0], "off") {
+			c.Enabled = false
+			fields = fields[1:]
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: expected <target> <value>", n)
+		}
+
+		var err error
+		if c.Target, err = ParseCheatTarget(fields[0]); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if c.Value, err = ParseCheatValue(fields[1]); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		fields = fields[2:]
+		if len(fields) > 0 && strings.ContainsAny(fields[0][:1], "=!<>") {
+			if c.Cond, err = ParseCheatCond(fields[0]); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			fields = fields[1:]
+		}
+		c.Description = strings.Join(fields, " ")
+
+		cheats = append(cheats, c)
+	}
+
+	return cheats, sc.Err()
+}
+
+// WriteCheats writes cheats in the format read by ParseCheats.
+func WriteCheats(w io.Writer, cheats []Cheat) error {
+	for _, c := range cheats {
+		fields := []string{c.Target.String(), fmt.Sprintf("0x%02X", c.Value)}
+		if !c.Enabled {
+			fields = append([]string{"off"}, fields...)
+		}
+		if c.Cond.Op != "" {
+			fields = append(fields, c.Cond.String())
+		}
+		if c.Description != "" {
+			fields = append(fields, c.Description)
+		}
+
+		if _, err := fmt.Fprintln(w, strings.Join(fields, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheatKey is the Store key of the cheat file for the loaded ROM.
+func (e *Emu) CheatKey() string {
+	return "cheats/" + e.ROMHash + ".cht"
+}
+
+// LoadCheats replaces Cheats with the cheat file of the loaded ROM. A missing
+// file or a nil Store leaves no cheats and is not an error.
+func (e *Emu) LoadCheats() error {
+	e.Cheats = nil
+	if e.Store == nil || !e.Loaded() {
+		return nil
+	}
+
+	data, err := e.Store.Load(e.CheatKey())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	cheats, err := ParseCheats(strings.NewReader(string(data)))
+	if err != nil {
+		return fmt.Errorf("%s: %w", e.CheatKey(), err)
+	}
+	e.Cheats = cheats
+
+	slog.Info("Cheats loaded:", "count", len(cheats))
+	return nil
+}
+
+// SaveCheats writes Cheats to the cheat file of the loaded ROM.
+func (e *Emu) SaveCheats() error {
+	if e.Store == nil {
+		return errors.New("no store configured")
+	}
+	if !e.Loaded() {
+		return errors.New("no ROM loaded")
+	}
+
+	var b strings.Builder
+	if err := WriteCheats(&b, e.Cheats); err != nil {
+		return err
+	}
+	return e.Store.Save(e.CheatKey(), []byte(b.String()))
+}
+
+// CheatsOn reports whether cheats are applied at all. Individual cheats are
+// switched with their Enabled field.
+func (e *Emu) CheatsOn() bool {
+	return !e.cheatsOff
+}
+
+// ToggleCheats switches all cheats off or back on and returns the new state.
+func (e *Emu) ToggleCheats() bool {
+	e.cheatsOff = !e.cheatsOff
+	return !e.cheatsOff
+}
+
+// Freeze adds or updates an enabled cheat for t and applies it immediately.
+func (e *Emu) Freeze(t CheatTarget, val byte, desc string) {
+	c := Cheat{Target: t, Value: val, Enabled: true, Description: desc}
+
+	if i := e.cheatIndex(t); i >= 0 {
+		e.Cheats[i] = c
+	} else {
+		e.Cheats = append(e.Cheats, c)
+	}
+
+	t.Write(e.VM, val)
+}
+
+// Unfreeze removes the cheat for t and reports whether there was one.
+func (e *Emu) Unfreeze(t CheatTarget) bool {
+	i := e.cheatIndex(t)
+	if i < 0 {
+		return false
+	}
+
+	e.Cheats = append(e.Cheats[:i], e.Cheats[i+1:]...)
+	return true
+}
+
+// Poke writes val to t once.
+func (e *Emu) Poke(t CheatTarget, val byte) {
+	t.Write(e.VM, val)
+}
+
+// ApplyCheats rewrites every enabled cheat whose condition matches, unless
+// cheats are toggled off. RunFrame calls it after each frame; hosts stepping
+// the VM directly call it themselves.
+func (e *Emu) ApplyCheats() {
+	if e.cheatsOff {
+		return
+	}
+
+	for _, c := range e.Cheats {
+		if c.Enabled && c.Cond.Match(c.Target.Read(e.VM)) {
+			c.Target.Write(e.VM, c.Value)
+		}
+	}
+}
+
+func (e *Emu) cheatIndex(t CheatTarget) int {
+	for i, c := range e.Cheats {
+		if c.Target == t {
+			return i
+		}
+	}
+	return -1
+}
+
+// SearchMode selects how CheatSearch.Filter compares each candidate with its
+// value at the previous search step.
+type SearchMode int
+
+const (
+	SearchEqual SearchMode = iota // equal to the given value
+	SearchChanged
+	SearchUnchanged
+	SearchIncreased
+	SearchDecreased
+)
+
+var searchModeNames = map[string]SearchMode{
+	"eq":        SearchEqual,
+	"=":         SearchEqual,
+	"changed":   SearchChanged,
+	"unchanged": SearchUnchanged,
+	"inc":       SearchIncreased,
+	"increased": SearchIncreased,
+	"dec":       SearchDecreased,
+	"decreased": SearchDecreased,
+}
+
+func ParseSearchMode(s string) (SearchMode, error) {
+	m, ok := searchModeNames[strings.ToLower(s)]
+	if !ok {
+		return 0, fmt.Errorf("unknown search mode %q (use eq, changed, unchanged, inc or dec)", s)
+	}
+	return m, nil
+}
+
+// SearchResult is a candidate target and its value at the last search step.
+type SearchResult struct {
+	Target CheatTarget
+	Value  byte
+}
+
+// CheatSearch narrows down the memory addresses and V registers holding a
+// value, e.g. a lives counter, over repeated Filter calls.
+//
+// Start snapshots all targets for an unknown initial value; each Filter
+// keeps the candidates matching the mode and takes a new snapshot.
+type CheatSearch struct {
+	results []SearchResult
+	started bool
+}
+
+// Start makes every memory address and V register a candidate.
+func (s *CheatSearch) Start(vm *chip8.VM) {
+	s.results = make([]SearchResult, 0, chip8.MemorySize+16)
+
+	for addr := range chip8.MemorySize {
+		t := CheatTarget{Addr: uint16(addr)}
+		s.results = append(s.results, SearchResult{Target: t, Value: t.Read(vm)})
+	}
+	for x := range uint16(16) {
+		t := CheatTarget{Reg: true, Addr: x}
+		s.results = append(s.results, SearchResult{Target: t, Value: t.Read(vm)})
+	}
+
+	s.started = true
+}
+
+// Filter keeps the candidates whose current value matches mode and returns
+// how many remain. A search that was not started begins with every target.
+func (s *CheatSearch) Filter(vm *chip8.VM, mode SearchMode, value byte) int {
+	if !s.started {
+		s.Start(vm)
+	}
+
+	kept := s.results[:0]
+	for _, r := range s.results {
+		cur := r.Target.Read(vm)
+
+		var ok bool
+		switch mode {
+		case SearchEqual:
+			ok = cur == value
+		case SearchChanged:
+			ok = cur != r.Value
+		case SearchUnchanged:
+			ok = cur == r.Value
+		case SearchIncreased:
+			ok = cur > r.Value
+		case SearchDecreased:
+			ok = cur < r.Value
+		}
+
+		if ok {
+			kept = append(kept, SearchResult{Target: r.Target, Value: cur})
+		}
+	}
+	s.results = kept
+
+	return len(s.results)
+}
+
+func (s *CheatSearch) Started() bool {
+	return s.started
+}
+
+func (s *CheatSearch) Results() []SearchResult {
+	return s.results
+}
+
+func (s *CheatSearch) Reset() {
+	s.results = nil
+	s.started = false
+}
@@ -0,0 +1,203 @@
+package host
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCheatTarget(t *testing.T) {
+	tests := []struct {
+		in   string
+		want CheatTarget
+	}{
+		{"v5", CheatTarget{Reg: true, Addr: 5}},
+		{"VF", CheatTarget{Reg: true, Addr: 0xF}},
+		{"0x300", CheatTarget{Addr: 0x300}},
+		{"768", CheatTarget{Addr: 768}},
+	}
+	for _, tt := range tests {
+		got, err := ParseCheatTarget(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseCheatTarget(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"vG", "v10", "0x10000", "lives"} {
+		if _, err := ParseCheatTarget(in); err == nil {
+			t.Errorf("ParseCheatTarget(%q) expected an error", in)
+		}
+	}
+}
+
+func TestFreezeAppliesEveryFrame(t *testing.T) {
+	emu, _ := NewEmu()
+	// ADD V3, FF ; LD I, 0300 ; LD [I], V0-V3 ; JP 0200
+	if _, err := emu.LoadROM([]byte{0x73, 0xFF, 0xA3, 0x00, 0xF3, 0x55, 0x12, 0x00}, ".ch8"); err != nil {
+		t.Fatal(err)
+	}
+
+	v3 := CheatTarget{Reg: true, Addr: 3}
+	emu.Freeze(v3, 9, "lives")
+	emu.Freeze(v3, 7, "lives") // updates the existing entry
+	if len(emu.Cheats) != 1 {
+		t.Fatalf("len(Cheats) = %d, want 1", len(emu.Cheats))
+	}
+
+	emu.RunFrames(3)
+	if got := v3.Read(emu.VM); got != 7 {
+		t.Errorf("V3 = %d, want 7 after frame", got)
+	}
+
+	if !emu.Unfreeze(v3) || emu.Unfreeze(v3) {
+		t.Error("Unfreeze should report the cheat only once")
+	}
+
+	emu.RunFrames(1)
+	if got := v3.Read(emu.VM); got == 7 {
+		t.Error("V3 should change once released")
+	}
+}
+
+func TestParseCheats(t *testing.T) {
+	in := `# Brix
+v3 9 <9 Infinite lives
+off 0x2F0 0x00 Skip intro
+
+0x300 1
+`
+	want := []Cheat{
+		{Target: CheatTarget{Reg: true, Addr: 3}, Value: 9, Cond: CheatCond{Op: "<", Value: 9}, Enabled: true, Description: "Infinite lives"},
+		{Target: CheatTarget{Addr: 0x2F0}, Value: 0, Description: "Skip intro"},
+		{Target: CheatTarget{Addr: 0x300}, Value: 1, Enabled: true},
+	}
+
+	got, err := ParseCheats(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseCheats = %+v, want %+v", got, want)
+	}
+
+	var b strings.Builder
+	if err := WriteCheats(&b, got); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ParseCheats(strings.NewReader(b.String()))
+	if err != nil || !reflect.DeepEqual(again, want) {
+		t.Errorf("round trip of %q = %+v, %v", b.String(), again, err)
+	}
+
+	for _, in := range []string{"v3", "vX 1", "v3 256", "v3 1 <x"} {
+		if _, err := ParseCheats(strings.NewReader(in)); err == nil || !strings.HasPrefix(err.Error(), "line 1:") {
+			t.Errorf("ParseCheats(%q) error = %v, want line 1 error", in, err)
+		}
+	}
+}
+
+func TestCheatCond(t *testing.T) {
+	c := CheatCond{Op: "<", Value: 9}
+	if !c.Match(8) || c.Match(9) {
+		t.Error("<9 should match 8 but not 9")
+	}
+	if !(CheatCond{}).Match(0xFF) {
+		t.Error("zero condition should always match")
+	}
+}
+
+func TestLoadROMLoadsCheats(t *testing.T) {
+	rom := []byte{0x73, 0x01, 0x12, 0x00} // ADD V3, 01 ; JP 0200
+	store := DirStore(t.TempDir())
+
+	emu, _ := NewEmu()
+	emu.Store = store
+	if _, err := emu.LoadROM(rom, ".ch8"); err != nil {
+		t.Fatal(err)
+	}
+	if len(emu.Cheats) != 0 {
+		t.Fatalf("Cheats = %v, want none without a file", emu.Cheats)
+	}
+
+	emu.Cheats = []Cheat{{Target: CheatTarget{Reg: true, Addr: 3}, Value: 5, Cond: CheatCond{Op: ">", Value: 5}, Enabled: true}}
+	if err := emu.SaveCheats(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := emu.LoadROM(rom, ".ch8"); err != nil {
+		t.Fatal(err)
+	}
+	if len(emu.Cheats) != 1 {
+		t.Fatalf("Cheats = %v, want the saved cheat", emu.Cheats)
+	}
+
+	emu.RunFrames(2)
+	if got := emu.VM.CPU.V(3); got != 5 {
+		t.Errorf("V3 = %d, want capped at 5", got)
+	}
+
+	if emu.ToggleCheats() || emu.CheatsOn() {
+		t.Fatal("ToggleCheats should switch cheats off")
+	}
+	emu.RunFrames(2)
+	if got := emu.VM.CPU.V(3); got <= 5 {
+		t.Errorf("V3 = %d, want past 5 with cheats off", got)
+	}
+}
+
+func TestPoke(t *testing.T) {
+	emu, _ := NewEmu()
+	mem := CheatTarget{Addr: 0x400}
+
+	emu.Poke(mem, 0x42)
+	if got := emu.VM.Memory.Read(0x400); got != 0x42 {
+		t.Errorf("mem[0400] = %02X, want 42", got)
+	}
+}
+
+func TestCheatSearch(t *testing.T) {
+	emu, _ := NewEmu()
+	vm := emu.VM
+	a := CheatTarget{Addr: 0x300}
+	b := CheatTarget{Addr: 0x301}
+	v := CheatTarget{Reg: true, Addr: 2}
+
+	a.Write(vm, 3)
+	b.Write(vm, 3)
+	v.Write(vm, 3)
+
+	s := CheatSearch{}
+	if n := s.Filter(vm, SearchEqual, 3); n != 3 {
+		t.Fatalf("eq 3: %d candidates, want 3", n)
+	}
+
+	a.Write(vm, 2) // lives lost
+	b.Write(vm, 4)
+	if n := s.Filter(vm, SearchDecreased, 0); n != 1 || s.Results()[0].Target != a {
+		t.Fatalf("dec: results = %v, want only %v", s.Results(), a)
+	}
+
+	if n := s.Filter(vm, SearchUnchanged, 0); n != 1 {
+		t.Errorf("unchanged: %d candidates, want 1", n)
+	}
+
+	s.Reset()
+	if s.Started() || len(s.Results()) != 0 {
+		t.Error("Reset should clear the search")
+	}
+
+	s.Start(vm)
+	v.Write(vm, 5)
+	if n := s.Filter(vm, SearchIncreased, 0); n != 1 || s.Results()[0].Target != v {
+		t.Errorf("inc: results = %v, want only %v", s.Results(), v)
+	}
+}
+
+func TestParseSearchMode(t *testing.T) {
+	if m, err := ParseSearchMode("DEC"); err != nil || m != SearchDecreased {
+		t.Errorf("ParseSearchMode(DEC) = %v, %v", m, err)
+	}
+	if _, err := ParseSearchMode("bigger"); err == nil {
+		t.Error("ParseSearchMode(bigger) expected an error")
+	}
+}
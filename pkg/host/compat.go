@@ -0,0 +1,118 @@
+package host
+
+import (
+	"fmt"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+)
+
+// compatWindow is the number of final frames inspected for a tight loop.
+const compatWindow = 60
+
+// CompatStatus classifies how a ROM behaved during CheckCompat.
+type CompatStatus int
+
+const (
+	CompatRan CompatStatus = iota
+	CompatBlank
+	CompatStuck
+	CompatUnknownOps
+	CompatFaulted
+)
+
+var compatStatusNames = [...]string{
+	CompatRan:        "ran",
+	CompatBlank:      "blank screen",
+	CompatStuck:      "stuck",
+	CompatUnknownOps: "unknown opcodes",
+	CompatFaulted:    "faulted",
+}
+
+func (s CompatStatus) String() string {
+	if int(s) < len(compatStatusNames) {
+		return compatStatusNames[s]
+	}
+	return fmt.Sprintf("CompatStatus(%d)", int(s))
+}
+
+// CompatResult reports the outcome of Emu.CheckCompat.
+type CompatResult struct {
+	Status     CompatStatus
+	Frames     int      // frames completed
+	Fault      string   // recovered panic, when Status is CompatFaulted
+	UnknownOps []uint16 // distinct unimplemented opcodes, in order first executed
+	Thumbnail  []byte   // PNG of the last frame
+}
+
+// CheckCompat runs the loaded ROM for frames frames without input and
+// classifies the outcome. The most severe finding wins: a panic, then
+// unknown opcodes, then a tight loop over at most two instructions during
+// the last second (key waits excluded), then a blank screen. The error
+// reports a thumbnail that could not be encoded; res is complete otherwise.
+func (e *Emu) CheckCompat(frames int) (res CompatResult, err error) {
+	seen := map[uint16]bool{}
+	window := map[uint16]bool{}
+	waitsForKey := false
+	windowStart := frames - compatWindow
+
+	e.VM.OnStep = func(pc, op uint16) {
+		if !chip8.KnownOp(op) && !seen[op] {
+			seen[op] = true
+			res.UnknownOps = append(res.UnknownOps, op)
+		}
+
+		if res.Frames >= windowStart {
+			window[pc] = true
+			if isKeyWait(op) {
+				waitsForKey = true
+			}
+		}
+	}
+	defer func() { e.VM.OnStep = nil }()
+
+	defer func() {
+		if r := recover(); r != nil {
+			res.Status = CompatFaulted
+			res.Fault = fmt.Sprint(r)
+		}
+		res.Thumbnail, err = e.FrameBuffer.PNG()
+	}()
+
+	for ; res.Frames < frames; res.Frames++ {
+		e.runFrame(FrameDelta)
+	}
+
+	switch {
+	case len(res.UnknownOps) > 0:
+		res.Status = CompatUnknownOps
+	case len(window) > 0 && len(window) <= 2 && !waitsForKey:
+		res.Status = CompatStuck
+	case displayBlank(&e.VM.Display):
+		res.Status = CompatBlank
+	default:
+		res.Status = CompatRan
+	}
+
+	return res, nil
+}
+
+func isKeyWait(op uint16) bool {
+	switch {
+	case op&0xF000 == 0xE000:
+		return op&0x00FF == 0x9E || op&0x00FF == 0xA1
+	case op&0xF000 == 0xF000:
+		return op&0x00FF == 0x0A
+	}
+	return false
+}
+
+func displayBlank(d *chip8.Display) bool {
+	for _, plane := range d.Planes {
+		for _, p := range plane {
+			if p != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
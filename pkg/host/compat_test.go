@@ -0,0 +1,76 @@
+package host
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCheckCompat(t *testing.T) {
+	tests := []struct {
+		name string
+		rom  []byte
+		want CompatStatus
+	}{
+		// LD I, 020A ; DRW V0, V0, 1 ; ADD V0, 01 ; CLS ; JP 0202 ; sprite
+		{"ran", []byte{0xA2, 0x0A, 0xD0, 0x01, 0x70, 0x01, 0x00, 0xE0, 0x12, 0x02, 0x80}, CompatRan},
+		// ADD V0, 01 ; ADD V1, 01 ; JP 0200
+		{"blank", []byte{0x70, 0x01, 0x71, 0x01, 0x12, 0x00}, CompatBlank},
+		// JP 0200
+		{"stuck", []byte{0x12, 0x00}, CompatStuck},
+		// LD V0, 05 ; SKP V0 ; JP 0202 (polls a key)
+		{"key wait", []byte{0x60, 0x05, 0xE0, 0x9E, 0x12, 0x02}, CompatBlank},
+		// SYS 123 ; JP 0202
+		{"unknown", []byte{0x01, 0x23, 0x12, 0x02}, CompatUnknownOps},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			emu, _ := NewEmu()
+			if _, err := emu.LoadROM(tt.rom, ".ch8"); err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := emu.CheckCompat(120)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Status != tt.want {
+				t.Errorf("Status = %v, want %v", res.Status, tt.want)
+			}
+			if res.Frames != 120 {
+				t.Errorf("Frames = %d, want 120", res.Frames)
+			}
+			if len(res.Thumbnail) == 0 {
+				t.Error("missing thumbnail")
+			}
+			if emu.VM.OnStep != nil {
+				t.Error("OnStep hook should be removed")
+			}
+		})
+	}
+}
+
+func TestCheckCompatUnknownOps(t *testing.T) {
+	emu, _ := NewEmu()
+	// SYS 123 ; .DW 8008 ; SYS 123 ; JP 0200
+	if _, err := emu.LoadROM([]byte{0x01, 0x23, 0x80, 0x08, 0x01, 0x23, 0x12, 0x00}, ".ch8"); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := emu.CheckCompat(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint16{0x0123, 0x8008}; !slices.Equal(res.UnknownOps, want) {
+		t.Errorf("UnknownOps = %04X, want %04X", res.UnknownOps, want)
+	}
+}
+
+func TestCompatStatusString(t *testing.T) {
+	if got := CompatStuck.String(); got != "stuck" {
+		t.Errorf("String() = %q, want %q", got, "stuck")
+	}
+	if got := CompatStatus(99).String(); got != "CompatStatus(99)" {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,155 @@
+package host
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+)
+
+// Display filters used when a frontend scales the frame to its window.
+const (
+	FilterNearest = "nearest"
+	FilterLinear  = "linear"
+)
+
+// Config holds user defaults shared by all frontends. It is read from
+// config.json in the ch8go user config directory, for example:
+//
+//	{
+//	  "scale": 8,
+//	  "volume": 30,
+//	  "palette": ["#1d2b53", "#ffccaa"],
+//	  "layout": "azerty",
+//	  "keymap": {"K": "5", "Numpad8": "5"},
+//	  "turbo": {"Space": "6"},
+//	  "filter": "linear",
+//	  "platform": "xo",
+//	  "quirks": {"vblank": false}
+//	}
+type Config struct {
+	Scale  int  `json:"scale,omitempty"`
+	Volume *int `json:"volume,omitempty"` // percent, 0-100
+	Mute   bool `json:"mute,omitempty"`
+	// Palette replaces the leading colors of DefaultPalette, background
+	// first.
+	Palette []string `json:"palette,omitempty"`
+	// Layout is the keyboard layout, see KeyLayouts.
+	Layout string `json:"layout,omitempty"`
+	// Keymap binds host key names to CHIP-8 keys "0"-"F", on top of the
+	// layout and the ROM's gamepad mapping. Names are those of
+	// NormalizeKeyName, such as "K", "Up", "Numpad8" or "Pad1A".
+	Keymap map[string]string `json:"keymap,omitempty"`
+	// Turbo binds host keys that press their CHIP-8 key repeatedly while
+	// held, TurboRate times a second (DefaultTurboRate if 0).
+	Turbo     map[string]string `json:"turbo,omitempty"`
+	TurboRate int               `json:"turboRate,omitempty"`
+	Filter    string            `json:"filter,omitempty"`   // FilterNearest or FilterLinear
+	Platform  string            `json:"platform,omitempty"` // ch8, sc or xo for ROMs nothing else identifies
+	Tickrate  int               `json:"tickrate,omitempty"`
+	Quirks    map[string]bool   `json:"quirks,omitempty"` // database quirk names, see QuirkNames
+	// Database is a CHIP-8 database directory merged into the embedded one,
+	// or replacing it with DatabaseReplace.
+	Database        string `json:"database,omitempty"`
+	DatabaseReplace bool   `json:"databaseReplace,omitempty"`
+	// Library lists ROM directories to catalog, see Library.
+	Library []string `json:"library,omitempty"`
+}
+
+// DefaultConfigPath returns config.json in the ch8go user config
+// directory, or "" if the platform has none.
+func DefaultConfigPath() string {
+	store, err := UserStore()
+	if err != nil {
+		return ""
+	}
+	return store.Path("config.json")
+}
+
+// LoadConfig reads the config file at path. A missing file yields an empty
+// Config unless required is set.
+func LoadConfig(path string, required bool) (Config, error) {
+	var c Config
+	if path == "" {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Save writes c as indented JSON to path.
+func (c *Config) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// quirkSpec formats Quirks in the form accepted by ParseQuirks.
+func (c *Config) quirkSpec() string {
+	names := make([]string, 0, len(c.Quirks))
+	for name := range c.Quirks {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s=%t", name, c.Quirks[name])
+	}
+	return strings.Join(parts, ",")
+}
+
+// UserConf holds the user defaults applied by every Emu.LoadROM.
+type UserConf struct {
+	Palette  *Palette       // replaces DefaultPalette when set
+	Platform chip8.Platform // for ROMs whose extension and metadata name none
+	Tickrate int            // for ROMs whose metadata sets none, when > 0
+	Quirks   string         // in the form accepted by ParseQuirks; metadata wins
+}
+
+// basePalette is the palette a ROM starts with before its metadata colors.
+func (e *Emu) basePalette() Palette {
+	if e.User.Palette != nil {
+		return *e.User.Palette
+	}
+	return DefaultPalette
+}
+
+// userConf sets the tickrate and quirk defaults on conf, below the
+// database and Octo options layers.
+func (e *Emu) userConf(conf *chip8.PlatformConf, sources QuirkSources) {
+	if e.User.Tickrate > 0 {
+		conf.Tickrate = e.User.Tickrate
+	}
+	if e.User.Quirks != "" {
+		names, err := applyQuirkSpec(e.User.Quirks, &conf.Quirks)
+		if err != nil {
+			slog.Error("Failed to apply user quirks", "err", err)
+		}
+		sources.set(names, "user config")
+	}
+}
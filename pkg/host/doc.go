@@ -3,5 +3,5 @@
 //
 // It contains code that integrates the CHIP-8 virtual machine with
 // concrete runtime environments (CLI, desktop, mobile, or web) and
-// may depend on pkg/chip8 and pkg/db.
+// may depend on pkg/chip8, pkg/db and pkg/patch.
 package host
@@ -2,14 +2,15 @@ package host
 
 import (
 	"log/slog"
-	"os"
-	"path/filepath"
 	"time"
 
 	"github.com/mxmgorin/ch8go/pkg/chip8"
 	"github.com/mxmgorin/ch8go/pkg/db"
 )
 
+// FrameDelta is the duration of one emulated frame at 60 FPS.
+const FrameDelta = time.Second / 60
+
 // Emu represents a host-level emulator instance.
 //
 // It binds a CHIP-8 virtual machine to host-provided services such as
@@ -20,9 +21,23 @@ type Emu struct {
 	VM            *chip8.VM
 	MetaDB        *db.MetaDB
 	ROMHash       string
+	SourceHash    string // of the ROM before patches; the MetaDB and Library know it by this
 	Palette       Palette
+	User          UserConf     // user defaults applied by LoadROM
+	Override      ROMOverride  // user corrections for the loaded ROM
+	QuirkSources  QuirkSources // where LoadROM took each quirk from
 	Paused        bool
 	FrameBuffer   FrameBuffer
+	Audio         *AudioStream // optional; rendered every frame when set
+	Cheats        []Cheat      // applied after every frame
+	Store         Store        // optional; per-ROM cheat files and flags
+	Library       *Library     // optional; OpenROM looks up titles in it
+	ROMOptions    *OctoOptions // options loaded with the ROM, e.g. from a cartridge
+	Input         *InputMapper // optional; rebound by LoadROM, applied every frame
+	PersistFlags  bool         // load and save RPL user flags through Store
+	cheatsOff     bool
+	savedFlags    [16]byte
+	frameRecorder *FrameRecorder
 	lastFrameTime time.Time
 }
 
@@ -42,35 +57,129 @@ func NewEmu() (*Emu, error) {
 		FrameBuffer:   newFrameBuffer(size.Width, size.Height, 4),
 		lastFrameTime: time.Now(),
 		Palette:       DefaultPalette,
+		PersistFlags:  true,
 	}, nil
 }
 
-func (e *Emu) Loaded() bool {
-	return e.ROMHash != ""
+// EnableAudio attaches a PCM stream that is rendered every frame and returns
+// it so a host audio backend can drain it.
+func (e *Emu) EnableAudio(sampleRate int) *AudioStream {
+	e.Audio = NewAudioStream(sampleRate)
+	return e.Audio
 }
 
-func (e *Emu) ReadROM(path string) (int, error) {
-	rom, err := os.ReadFile(path)
-	if err != nil {
-		return 0, err
+// StartAudioRecording captures every sample rendered from now on, enabling
+// audio at the default sample rate if no stream is attached yet.
+func (e *Emu) StartAudioRecording() *AudioRecorder {
+	if e.Audio == nil {
+		e.EnableAudio(DefaultSampleRate)
 	}
 
-	return e.LoadROM(rom, filepath.Ext(path))
+	e.Audio.recorder = NewAudioRecorder(e.Audio.SampleRate)
+	return e.Audio.recorder
+}
+
+// StopAudioRecording detaches and returns the active recorder, or nil if
+// nothing is being recorded.
+func (e *Emu) StopAudioRecording() *AudioRecorder {
+	if e.Audio == nil {
+		return nil
+	}
+
+	rec := e.Audio.recorder
+	e.Audio.recorder = nil
+	return rec
+}
+
+func (e *Emu) AudioRecording() bool {
+	return e.Audio != nil && e.Audio.recorder != nil
+}
+
+// StartVideoRecording captures every frame from now on at the given scale.
+func (e *Emu) StartVideoRecording(scale int) *FrameRecorder {
+	e.frameRecorder = NewFrameRecorder(scale)
+	return e.frameRecorder
+}
+
+// StopVideoRecording detaches and returns the active recorder, or nil if
+// nothing is being recorded.
+func (e *Emu) StopVideoRecording() *FrameRecorder {
+	rec := e.frameRecorder
+	e.frameRecorder = nil
+	return rec
+}
+
+func (e *Emu) VideoRecording() bool {
+	return e.frameRecorder != nil
+}
+
+func (e *Emu) Loaded() bool {
+	return e.ROMHash != ""
 }
 
 func (e *Emu) LoadROM(rom []byte, ext string) (int, error) {
-	e.Palette = DefaultPalette
-	e.ROMHash = db.SHA1Of(rom)
-	len := len(rom)
+	return e.LoadROMWithOptions(rom, ext, nil)
+}
+
+// LoadROMWithOptions loads rom with Octo options that take precedence over
+// the user defaults and the MetaDB but not over the ROM override.
+func (e *Emu) LoadROMWithOptions(rom []byte, ext string, opts *OctoOptions) (int, error) {
+	return e.loadROM(rom, ext, opts, "")
+}
 
-	slog.Info("ROM loaded:", "size", len, "hash", e.ROMHash, "ext", ext)
+// loadROM is LoadROMWithOptions for rom patched from the ROM with hash
+// source, or unpatched if source is empty. Nothing changes if rom fails to
+// load.
+func (e *Emu) loadROM(rom []byte, ext string, opts *OctoOptions, source string) (int, error) {
+	if opts != nil {
+		if err := opts.Validate(); err != nil {
+			return 0, err
+		}
+	}
 
 	if err := e.VM.LoadROM(rom); err != nil {
 		return 0, err
 	}
 
+	e.Palette = e.basePalette()
+	e.ROMOptions = opts
+	e.ROMHash = db.SHA1Of(rom)
+	e.SourceHash = e.ROMHash
+	if source != "" {
+		e.SourceHash = source
+	}
+	len := len(rom)
+
+	slog.Info("ROM loaded:", "size", len, "hash", e.ROMHash, "source", e.SourceHash, "ext", ext)
+
+	if e.Library != nil {
+		if err := e.Library.MarkPlayed(e.SourceHash, time.Now()); err != nil {
+			slog.Error("Failed to save library", "err", err)
+		}
+	}
+
+	if err := e.LoadCheats(); err != nil {
+		slog.Error("Failed to load cheats", "err", err)
+	}
+
+	if err := e.loadFlags(); err != nil {
+		slog.Error("Failed to load flags", "err", err)
+	}
+
+	if err := e.loadOverride(); err != nil {
+		slog.Error("Failed to load ROM override", "err", err)
+	}
+
 	rm := e.ROMMeta()
-	rc := e.ROMConf(rm, ext)
+	rc, sources := e.romConf(rm, ext)
+	e.QuirkSources = sources
+	rotation := 0
+	if rm != nil {
+		rotation = rm.ScreenRotation
+	}
+	if opts != nil && opts.ScreenRotation != nil {
+		rotation = *opts.ScreenRotation
+	}
 	e.VM.SetConf(rc)
 
 	if rm != nil {
@@ -85,15 +194,51 @@ func (e *Emu) LoadROM(rom []byte, ext string) (int, error) {
 		}
 	}
 
+	if opts != nil {
+		// Validated above.
+		_ = opts.applyPalette(&e.Palette)
+	}
+
+	if e.Override.Rotation != nil {
+		rotation = *e.Override.Rotation
+	}
+	// Validated by MetaDB and loadOverride.
+	_ = e.SetRotation(rotation)
+
+	e.applyOverride()
+	if e.Input != nil {
+		keys, _ := e.Override.Keys() // validated by loadOverride
+		e.Input.bindROM(rm, keys)
+	}
+	slog.Info("Quirks:", "sources", e.QuirkSources.Report(e.VM.CPU.Quirks))
+
 	return len, nil
 }
 
+// Rotation returns the clockwise degrees the display is shown rotated by.
+func (e *Emu) Rotation() int {
+	return e.FrameBuffer.Rotation
+}
+
+// SetRotation shows the display rotated clockwise by deg: 0, 90, 180 or
+// 270. Frontends size their output from FrameBuffer.Width and Height.
+func (e *Emu) SetRotation(deg int) error {
+	return e.FrameBuffer.SetRotation(deg, &e.Palette, &e.VM.Display)
+}
+
+// Rotate turns the display a further 90 degrees clockwise and returns the
+// new rotation.
+func (e *Emu) Rotate() int {
+	_ = e.SetRotation((e.Rotation() + 90) % 360)
+	return e.Rotation()
+}
+
 func (e *Emu) ROMMeta() *db.ROMMeta {
-	return e.MetaDB.ROM(e.ROMHash)
+	return e.MetaDB.ROM(e.SourceHash)
 }
 
 func (e *Emu) ROMInfo() string {
-	program := e.MetaDB.Program(e.ROMHash)
+	program := e.MetaDB.Program(e.SourceHash)
 	if program == nil {
 		return "Unknown"
 	}
@@ -101,21 +246,46 @@ func (e *Emu) ROMInfo() string {
 }
 
 func (e *Emu) ROMConf(meta *db.ROMMeta, ext string) chip8.PlatformConf {
+	conf, _ := e.romConf(meta, ext)
+	return conf
+}
+
+// romConf is ROMConf also reporting where each quirk came from.
+func (e *Emu) romConf(meta *db.ROMMeta, ext string) (chip8.PlatformConf, QuirkSources) {
 	conf := chip8.DefaultConf
+	sources := QuirkSources{}
+	sources.setAll("default")
+
 	platform, ok := chip8.PlatformByExt[ext]
+	source := "extension " + ext
+	if !ok && e.User.Platform != "" {
+		platform, ok = e.User.Platform, true
+		source = "user platform " + string(platform)
+	}
 	if ok {
 		platConf, ok := chip8.ConfByPlatform[platform]
 		if ok {
 			conf = platConf
+			sources.setAll(source)
 		}
 	}
+	e.userConf(&conf, sources)
 
 	if meta == nil {
 		slog.Info("Unknown ROM")
-		return conf
+	} else {
+		e.metaConf(meta, &conf, sources)
 	}
 
-	for _, id := range meta.Platforms {
+	if e.ROMOptions != nil {
+		e.ROMOptions.applyConf(&conf, sources)
+	}
+	return conf, sources
+}
+
+// metaConf sets the platform, quirks, tickrate and font of meta on conf.
+func (e *Emu) metaConf(meta *db.ROMMeta, conf *chip8.PlatformConf, sources QuirkSources) {
+	for _, id := range meta.PlatformIDs() {
 		if id != "megachip8" { // not supported
 			platform := e.MetaDB.Platform(id)
 
@@ -131,11 +301,18 @@ func (e *Emu) ROMConf(meta *db.ROMMeta, ext string) chip8.PlatformConf {
 					ResetFlag:   platform.Quirks.Logic,
 					ScaleScroll: platform.Quirks.ScaleScroll,
 				}
+				sources.setAll("platform " + id)
 
 				if platform.DefaultTickrate > 0 {
 					conf.Tickrate = platform.DefaultTickrate
 				}
 
+				// Validated by MetaDB.
+				for name, v := range meta.QuirkyPlatforms[id] {
+					*QuirkField(&conf.Quirks, name) = v
+					sources[name] = "quirkyPlatforms " + id
+				}
+
 				break
 			}
 		}
@@ -145,7 +322,11 @@ func (e *Emu) ROMConf(meta *db.ROMMeta, ext string) chip8.PlatformConf {
 		conf.Tickrate = meta.Tickrate
 	}
 
-	return conf
+	if font := chip8.FontStyle(meta.FontStyle); chip8.ValidFont(font) {
+		conf.Font = font
+	} else {
+		slog.Warn("Unsupported font style:", "font", font)
+	}
 }
 
 func (e *Emu) RunFrame() *FrameBuffer {
@@ -156,10 +337,36 @@ func (e *Emu) RunFrame() *FrameBuffer {
 	return e.runFrame(frameDelta)
 }
 
+// RunFrames runs n frames of FrameDelta each without real-time pacing, for
+// headless and scripted runs.
+func (e *Emu) RunFrames(n int) *FrameBuffer {
+	for range n {
+		e.runFrame(FrameDelta)
+	}
+	return &e.FrameBuffer
+}
+
 func (e *Emu) runFrame(frameDelta time.Duration) *FrameBuffer {
 	if e.Loaded() && !e.Paused {
+		if e.Audio != nil {
+			// Render before stepping so a sound timer set during this frame
+			// is heard for its full duration starting next frame.
+			e.Audio.Render(&e.VM.Audio, frameDelta)
+		}
+
+		if e.Input != nil {
+			e.Input.Apply(&e.VM.Keypad)
+		}
 		state := e.VM.RunFrame(frameDelta)
+		e.ApplyCheats()
+		if err := e.SaveFlags(); err != nil {
+			slog.Error("Failed to save flags", "err", err)
+		}
 		e.FrameBuffer.Update(state, &e.Palette, &e.VM.Display)
+
+		if e.frameRecorder != nil {
+			e.frameRecorder.Add(&e.FrameBuffer, &e.Palette, frameDelta)
+		}
 	}
 	return &e.FrameBuffer
 }
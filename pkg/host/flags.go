@@ -0,0 +1,57 @@
+package host
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// FlagsKey is the Store key of the RPL user flags saved for the loaded ROM.
+func (e *Emu) FlagsKey() string {
+	return "flags/" + e.ROMHash + ".bin"
+}
+
+func (e *Emu) flagsPersisted() bool {
+	return e.PersistFlags && e.Store != nil && e.Loaded()
+}
+
+// loadFlags restores the flags saved for the loaded ROM, or clears them so
+// high scores of another ROM do not leak in.
+func (e *Emu) loadFlags() error {
+	e.savedFlags = [16]byte{}
+	e.VM.CPU.SetFlags(e.savedFlags)
+	if !e.flagsPersisted() {
+		return nil
+	}
+
+	data, err := e.Store.Load(e.FlagsKey())
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if len(data) > len(e.savedFlags) {
+		return fmt.Errorf("%s: %d bytes, want at most %d", e.FlagsKey(), len(data), len(e.savedFlags))
+	}
+
+	copy(e.savedFlags[:], data)
+	e.VM.CPU.SetFlags(e.savedFlags)
+	return nil
+}
+
+// SaveFlags writes the RPL user flags of the loaded ROM when FX75 changed
+// them since the last save. RunFrame calls it after each frame; hosts
+// stepping the VM directly call it themselves.
+func (e *Emu) SaveFlags() error {
+	if !e.flagsPersisted() {
+		return nil
+	}
+
+	flags := e.VM.CPU.Flags()
+	if flags == e.savedFlags {
+		return nil
+	}
+
+	// Remember the flags even if saving fails so a broken store is reported
+	// once per change rather than every frame.
+	e.savedFlags = flags
+	return e.Store.Save(e.FlagsKey(), flags[:])
+}
@@ -0,0 +1,69 @@
+package host
+
+import (
+	"testing"
+)
+
+// LD V0, 2A ; LD V1, 07 ; LD F, V1 (FX75) ; JP 0206
+var flagsROM = []byte{0x60, 0x2A, 0x61, 0x07, 0xF1, 0x75, 0x12, 0x06}
+
+func TestFlagsPersistPerROM(t *testing.T) {
+	store := DirStore(t.TempDir())
+
+	emu, _ := NewEmu()
+	emu.Store = store
+	if _, err := emu.LoadROM(flagsROM, ".ch8"); err != nil {
+		t.Fatal(err)
+	}
+	emu.RunFrames(1)
+
+	data, err := store.Load(emu.FlagsKey())
+	if err != nil {
+		t.Fatalf("flags not saved: %v", err)
+	}
+	if len(data) != 16 || data[0] != 0x2A || data[1] != 0x07 {
+		t.Fatalf("saved flags = % X", data)
+	}
+
+	// A new session restores them before the ROM runs.
+	next, _ := NewEmu()
+	next.Store = store
+	if _, err := next.LoadROM(flagsROM, ".ch8"); err != nil {
+		t.Fatal(err)
+	}
+	if got := next.VM.CPU.Flags(); got[0] != 0x2A || got[1] != 0x07 {
+		t.Errorf("restored flags = % X", got)
+	}
+
+	// Another ROM starts with clear flags.
+	if _, err := next.LoadROM([]byte{0x12, 0x00}, ".ch8"); err != nil {
+		t.Fatal(err)
+	}
+	if got := next.VM.CPU.Flags(); got != [16]byte{} {
+		t.Errorf("flags of another ROM = % X, want zero", got)
+	}
+}
+
+func TestFlagsNotPersistedWhenDisabled(t *testing.T) {
+	store := DirStore(t.TempDir())
+
+	emu, _ := NewEmu()
+	emu.Store = store
+	emu.PersistFlags = false
+	if _, err := emu.LoadROM(flagsROM, ".ch8"); err != nil {
+		t.Fatal(err)
+	}
+	emu.RunFrames(1)
+
+	if _, err := store.Load(emu.FlagsKey()); err == nil {
+		t.Error("flags saved although PersistFlags is false")
+	}
+
+	// Nor do they leak into the next ROM.
+	if _, err := emu.LoadROM([]byte{0x12, 0x00}, ".ch8"); err != nil {
+		t.Fatal(err)
+	}
+	if got := emu.VM.CPU.Flags(); got != [16]byte{} {
+		t.Errorf("flags of another ROM = % X, want zero", got)
+	}
+}
@@ -0,0 +1,90 @@
+package host
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+	"github.com/mxmgorin/ch8go/pkg/db"
+)
+
+// GamepadButton is a logical gamepad button, named as in ROMMeta.Keys.
+type GamepadButton string
+
+const (
+	ButtonUp    GamepadButton = "up"
+	ButtonDown  GamepadButton = "down"
+	ButtonLeft  GamepadButton = "left"
+	ButtonRight GamepadButton = "right"
+	ButtonA     GamepadButton = "a"
+	ButtonB     GamepadButton = "b"
+)
+
+// GamepadButtons lists the logical buttons.
+var GamepadButtons = []GamepadButton{ButtonUp, ButtonDown, ButtonLeft, ButtonRight, ButtonA, ButtonB}
+
+// DefaultGamepad is the mapping of the first gamepad for ROMs without key
+// metadata: Octo's WASD and E/Q layout, which most modern games use.
+var DefaultGamepad = GamepadMap{
+	ButtonUp:    chip8.Key5,
+	ButtonDown:  chip8.Key8,
+	ButtonLeft:  chip8.Key7,
+	ButtonRight: chip8.Key9,
+	ButtonA:     chip8.Key6,
+	ButtonB:     chip8.Key4,
+}
+
+// StickDeadzone is how far an analog stick must be pushed, out of 1, to
+// press a direction.
+const StickDeadzone = 0.5
+
+// GamepadMap maps logical gamepad buttons to CHIP-8 keys.
+type GamepadMap map[GamepadButton]chip8.Key
+
+// NewGamepadMap returns the mapping of gamepad player (1 or 2) for a ROM.
+// The keys of meta replace the default: "up" and the like for the first
+// player, "player2Up" and the like for the second. Only the first player
+// has a default mapping.
+func NewGamepadMap(meta *db.ROMMeta, player int) GamepadMap {
+	if meta == nil || len(meta.Keys) == 0 {
+		if player == 1 {
+			return DefaultGamepad
+		}
+		return GamepadMap{}
+	}
+
+	m := GamepadMap{}
+	for _, b := range GamepadButtons {
+		name := string(b)
+		if player > 1 {
+			name = "player2" + strings.ToUpper(name[:1]) + name[1:]
+		}
+		if key, ok := meta.Keys[name]; ok {
+			if key < 0 || key >= int(chip8.KeyCount) {
+				slog.Warn("Invalid key in ROM metadata:", "button", name, "key", key)
+				continue
+			}
+			m[b] = chip8.Key(key)
+		}
+	}
+	return m
+}
+
+// StickButtons returns the directions an analog stick points to, with x
+// and y from -1 to 1 and y growing downwards.
+func StickButtons(x, y float64) []GamepadButton {
+	var buttons []GamepadButton
+	switch {
+	case x <= -StickDeadzone:
+		buttons = append(buttons, ButtonLeft)
+	case x >= StickDeadzone:
+		buttons = append(buttons, ButtonRight)
+	}
+	switch {
+	case y <= -StickDeadzone:
+		buttons = append(buttons, ButtonUp)
+	case y >= StickDeadzone:
+		buttons = append(buttons, ButtonDown)
+	}
+	return buttons
+}
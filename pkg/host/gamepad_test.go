@@ -0,0 +1,57 @@
+package host
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+)
+
+func TestNewGamepadMap(t *testing.T) {
+	emu, _ := NewEmu()
+	hash, ok := emu.MetaDB.HashByFile("Pong 2 (Pong hack) [David Winter, 1997].ch8")
+	if !ok {
+		t.Fatal("Pong 2 not in the database")
+	}
+	pong := emu.MetaDB.ROM(hash)
+
+	tests := []struct {
+		name   string
+		player int
+		want   GamepadMap
+	}{
+		{"pong player 1", 1, GamepadMap{ButtonUp: chip8.Key1, ButtonDown: chip8.Key4}},
+		{"pong player 2", 2, GamepadMap{ButtonUp: chip8.KeyC, ButtonDown: chip8.KeyD}},
+	}
+	for _, tt := range tests {
+		if got := NewGamepadMap(pong, tt.player); !maps.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if got := NewGamepadMap(nil, 1); !maps.Equal(got, DefaultGamepad) {
+		t.Errorf("unknown ROM: got %v, want the default", got)
+	}
+	if got := NewGamepadMap(nil, 2); len(got) != 0 {
+		t.Errorf("unknown ROM player 2: got %v, want no mapping", got)
+	}
+}
+
+func TestStickButtons(t *testing.T) {
+	tests := []struct {
+		x, y float64
+		want []GamepadButton
+	}{
+		{0, 0, nil},
+		{0.3, -0.4, nil},
+		{-0.9, 0, []GamepadButton{ButtonLeft}},
+		{0.7, 0.8, []GamepadButton{ButtonRight, ButtonDown}},
+		{0, -1, []GamepadButton{ButtonUp}},
+	}
+	for _, tt := range tests {
+		if got := StickButtons(tt.x, tt.y); !slices.Equal(got, tt.want) {
+			t.Errorf("StickButtons(%v, %v) = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package host
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+	"github.com/mxmgorin/ch8go/pkg/db"
+)
+
+// Keyboard layouts of InputMapper.
+const (
+	LayoutQWERTY = "qwerty"
+	LayoutAZERTY = "azerty"
+	LayoutDvorak = "dvorak"
+	LayoutNumpad = "numpad"
+)
+
+// DefaultTurboRate is how many times per second a turbo binding presses
+// its key.
+const DefaultTurboRate = 10
+
+// padOrder lists the CHIP-8 keys as they sit on the hex keypad, row by row.
+var padOrder = [chip8.KeyCount]chip8.Key{
+	chip8.Key1, chip8.Key2, chip8.Key3, chip8.KeyC,
+	chip8.Key4, chip8.Key5, chip8.Key6, chip8.KeyD,
+	chip8.Key7, chip8.Key8, chip8.Key9, chip8.KeyE,
+	chip8.KeyA, chip8.Key0, chip8.KeyB, chip8.KeyF,
+}
+
+// KeyLayouts gives the host key names of each layout in padOrder. The
+// letter layouts keep the hex keypad's shape on the left of the keyboard;
+// the numpad layout maps digits to themselves and A-F to the keys around
+// them.
+var KeyLayouts = map[string][chip8.KeyCount]string{
+	LayoutQWERTY: {
+		"1", "2", "3", "4",
+		"Q", "W", "E", "R",
+		"A", "S", "D", "F",
+		"Z", "X", "C", "V",
+	},
+	LayoutAZERTY: {
+		"1", "2", "3", "4",
+		"A", "Z", "E", "R",
+		"Q", "S", "D", "F",
+		"W", "X", "C", "V",
+	},
+	LayoutDvorak: {
+		"1", "2", "3", "4",
+		"Quote", "Comma", "Period", "P",
+		"A", "O", "E", "U",
+		"Semicolon", "Q", "J", "K",
+	},
+	LayoutNumpad: {
+		"Numpad1", "Numpad2", "Numpad3", "NumpadSubtract",
+		"Numpad4", "Numpad5", "Numpad6", "NumpadAdd",
+		"Numpad7", "Numpad8", "Numpad9", "NumpadEnter",
+		"NumpadDivide", "Numpad0", "NumpadMultiply", "NumpadDecimal",
+	},
+}
+
+// Layouts returns the names of KeyLayouts, sorted.
+func Layouts() []string {
+	return slices.Sorted(maps.Keys(KeyLayouts))
+}
+
+// LayoutKey returns the CHIP-8 key that name is bound to in layout.
+func LayoutKey(layout, name string) (chip8.Key, bool) {
+	name = NormalizeKeyName(name)
+	names := KeyLayouts[layout]
+	for i, n := range names {
+		if n == name {
+			return padOrder[i], true
+		}
+	}
+	return 0, false
+}
+
+// keyboardPad binds keys that act as the first gamepad, so that ROMs with
+// key metadata get arrow key controls.
+var keyboardPad = map[string]GamepadButton{
+	"Up":    ButtonUp,
+	"Down":  ButtonDown,
+	"Left":  ButtonLeft,
+	"Right": ButtonRight,
+	"Space": ButtonA,
+}
+
+// GamepadKey returns the host key name of a gamepad button, such as
+// "Pad1Up" for ButtonUp of player 1.
+func GamepadKey(player int, b GamepadButton) string {
+	return fmt.Sprintf("Pad%d%s%s", player, strings.ToUpper(string(b[:1])), b[1:])
+}
+
+// binding is what a host key does.
+type binding struct {
+	key   chip8.Key
+	turbo bool
+}
+
+// InputMapper turns host key and button presses, given by the abstract
+// names of NormalizeKeyName, into CHIP-8 key presses. Bindings are layered,
+// later ones replacing earlier ones for the same name:
+//
+//  1. the keyboard layout;
+//  2. the gamepads and the arrow keys and Space, which act as the first
+//     gamepad, mapped from the ROM's key metadata (see NewGamepadMap);
+//  3. the user keymap, then the user turbo bindings;
+//  4. the keymap of the ROM override.
+//
+// Several names may press the same key; it stays down while any of them is
+// held. Turbo bindings press and release their key TurboRate times a
+// second while held.
+type InputMapper struct {
+	TurboRate int // presses per second; 0 uses DefaultTurboRate
+
+	layout   string
+	keymap   map[string]chip8.Key
+	turbo    map[string]chip8.Key
+	rom      *db.ROMMeta
+	override map[string]chip8.Key
+
+	bindings map[string]binding
+	held     map[string]int // name → frame it was pressed at
+	frame    int
+	keys     [chip8.KeyCount]bool // as last applied
+}
+
+// NewInputMapper returns a mapper using layout with the user keymap and
+// turbo bindings on top.
+func NewInputMapper(layout string, keymap, turbo map[string]chip8.Key) (*InputMapper, error) {
+	m := &InputMapper{
+		keymap: normalizeKeys(keymap),
+		turbo:  normalizeKeys(turbo),
+		held:   map[string]int{},
+	}
+	if err := m.SetLayout(layout); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func normalizeKeys(keys map[string]chip8.Key) map[string]chip8.Key {
+	out := make(map[string]chip8.Key, len(keys))
+	for name, k := range keys {
+		out[NormalizeKeyName(name)] = k
+	}
+	return out
+}
+
+// Layout returns the keyboard layout in use.
+func (m *InputMapper) Layout() string {
+	return m.layout
+}
+
+// SetLayout switches to another of KeyLayouts; "" selects QWERTY.
+func (m *InputMapper) SetLayout(layout string) error {
+	if layout == "" {
+		layout = LayoutQWERTY
+	}
+	if _, ok := KeyLayouts[layout]; !ok {
+		return fmt.Errorf("unknown keyboard layout %q (use %s)", layout, strings.Join(Layouts(), ", "))
+	}
+	m.layout = layout
+	m.rebind()
+	return nil
+}
+
+// bindROM sets the bindings of the loaded ROM: its gamepad mapping and
+// override keymap.
+func (m *InputMapper) bindROM(meta *db.ROMMeta, override map[string]chip8.Key) {
+	m.rom = meta
+	m.override = normalizeKeys(override)
+	m.rebind()
+}
+
+func (m *InputMapper) rebind() {
+	b := map[string]binding{}
+	for i, name := range KeyLayouts[m.layout] {
+		b[name] = binding{key: padOrder[i]}
+	}
+
+	for player := 1; player <= 2; player++ {
+		for button, key := range NewGamepadMap(m.rom, player) {
+			b[GamepadKey(player, button)] = binding{key: key}
+			if player != 1 {
+				continue
+			}
+			for name, kb := range keyboardPad {
+				if kb == button {
+					b[name] = binding{key: key}
+				}
+			}
+		}
+	}
+
+	for name, key := range m.keymap {
+		b[name] = binding{key: key}
+	}
+	for name, key := range m.turbo {
+		b[name] = binding{key: key, turbo: true}
+	}
+	for name, key := range m.override {
+		b[name] = binding{key: key}
+	}
+	m.bindings = b
+}
+
+// Bound reports whether name is bound to a CHIP-8 key.
+func (m *InputMapper) Bound(name string) bool {
+	_, ok := m.bindings[NormalizeKeyName(name)]
+	return ok
+}
+
+// Bindings returns the names bound to each CHIP-8 key, sorted, with turbo
+// bindings marked by a trailing "*".
+func (m *InputMapper) Bindings() map[chip8.Key][]string {
+	keys := map[chip8.Key][]string{}
+	for name, b := range m.bindings {
+		if b.turbo {
+			name += "*"
+		}
+		keys[b.key] = append(keys[b.key], name)
+	}
+	for _, names := range keys {
+		slices.Sort(names)
+	}
+	return keys
+}
+
+// Handle records that the host key or button name went down or up. It
+// may be called again with the same state, e.g. by frontends that poll.
+// Keys change on the keypad at the next Apply.
+func (m *InputMapper) Handle(name string, down bool) {
+	name = NormalizeKeyName(name)
+	_, held := m.held[name]
+	switch {
+	case down && !held:
+		m.held[name] = m.frame
+	case !down && held:
+		delete(m.held, name)
+	}
+}
+
+// Apply presses and releases the keypad keys whose mapped state changed
+// since the last call. Keys pressed on the keypad by other means, such as
+// input scripts, are left alone. Call it once per frame; Emu.RunFrame does
+// when Emu.Input is set.
+func (m *InputMapper) Apply(keypad *chip8.Keypad) {
+	rate := m.TurboRate
+	if rate <= 0 {
+		rate = DefaultTurboRate
+	}
+	period := max(2, 60/rate) // frames per press and release
+
+	var keys [chip8.KeyCount]bool
+	for name, since := range m.held {
+		b, ok := m.bindings[name]
+		if !ok {
+			continue
+		}
+		if !b.turbo || (m.frame-since)%period < period/2 {
+			keys[b.key] = true
+		}
+	}
+
+	for k, down := range keys {
+		if down != m.keys[k] {
+			keypad.HandleKey(chip8.Key(k), down)
+		}
+	}
+	m.keys = keys
+	m.frame++
+}
+
+// keyNames are the canonical host key names besides letters, digits,
+// function keys and gamepad buttons.
+var keyNames = []string{
+	"Up", "Down", "Left", "Right", "Space", "Enter", "Tab", "Backspace", "Escape",
+	"Comma", "Period", "Semicolon", "Quote", "Slash", "Backslash", "Minus", "Equal",
+	"BracketLeft", "BracketRight", "Backquote",
+	"NumpadAdd", "NumpadSubtract", "NumpadMultiply", "NumpadDivide", "NumpadDecimal", "NumpadEnter",
+	"ShiftLeft", "ShiftRight", "ControlLeft", "ControlRight", "AltLeft", "AltRight",
+}
+
+// keyAliases maps the names frontends use to canonical ones.
+var keyAliases = map[string]string{
+	" ": "Space", ",": "Comma", ".": "Period", ";": "Semicolon", "'": "Quote",
+	"/": "Slash", "\\": "Backslash", "-": "Minus", "=": "Equal",
+	"[": "BracketLeft", "]": "BracketRight", "`": "Backquote",
+	"return": "Enter", "esc": "Escape",
+	"left shift": "ShiftLeft", "right shift": "ShiftRight",
+	"left ctrl": "ControlLeft", "right ctrl": "ControlRight",
+	"left alt": "AltLeft", "right alt": "AltRight",
+	"keypad +": "NumpadAdd", "keypad -": "NumpadSubtract", "keypad *": "NumpadMultiply",
+	"keypad /": "NumpadDivide", "keypad .": "NumpadDecimal", "keypad enter": "NumpadEnter",
+}
+
+// NormalizeKeyName returns the canonical name of a host key as reported by
+// SDL2 ("Up", "Keypad 1", "Left Shift"), Ebiten ("ArrowUp", "Digit1",
+// "Numpad1") or browsers ("ArrowUp", "a", "KeyA"). Canonical names are
+// uppercase letters, digits, "F1" to "F12", "Up", "Space", "Comma",
+// "Numpad1", "NumpadAdd", "ShiftLeft" and the like, and gamepad buttons
+// such as "Pad1Up". Other names are returned unchanged.
+func NormalizeKeyName(name string) string {
+	if name == "" {
+		return name
+	}
+	if alias, ok := keyAliases[strings.ToLower(name)]; ok {
+		return alias
+	}
+
+	if r := []rune(name); len(r) == 1 {
+		if unicode.IsLetter(r[0]) {
+			return strings.ToUpper(name)
+		}
+		return name
+	}
+
+	lower := strings.ToLower(name)
+	switch {
+	case len(name) == 4 && strings.HasPrefix(lower, "key") && unicode.IsLetter(rune(name[3])):
+		return strings.ToUpper(name[3:])
+	case len(name) == 6 && strings.HasPrefix(lower, "digit"):
+		return name[5:]
+	case strings.HasPrefix(lower, "arrow"):
+		name = name[len("arrow"):]
+	case strings.HasPrefix(lower, "keypad ") && len(name) == len("keypad 0"):
+		return "Numpad" + name[len("keypad "):]
+	case strings.HasPrefix(lower, "numpad") && len(name) == len("numpad0"):
+		return "Numpad" + name[len("numpad"):]
+	case lower[0] == 'f' && len(name) <= 3:
+		if _, err := strconv.Atoi(name[1:]); err == nil {
+			return "F" + name[1:]
+		}
+	}
+
+	for _, n := range keyNames {
+		if strings.EqualFold(n, name) {
+			return n
+		}
+	}
+	for player := 1; player <= 2; player++ {
+		for _, b := range GamepadButtons {
+			if n := GamepadKey(player, b); strings.EqualFold(n, name) {
+				return n
+			}
+		}
+	}
+	return name
+}
@@ -0,0 +1,157 @@
+package host
+
+import (
+	"os"
+	"testing"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+)
+
+func TestNormalizeKeyName(t *testing.T) {
+	tests := map[string]string{
+		"q":          "Q",
+		"KeyQ":       "Q",
+		"Digit1":     "1",
+		"1":          "1",
+		"ArrowUp":    "Up",
+		"Up":         "Up",
+		" ":          "Space",
+		"space":      "Space",
+		"Keypad 8":   "Numpad8",
+		"Numpad8":    "Numpad8",
+		"Keypad +":   "NumpadAdd",
+		"Left Shift": "ShiftLeft",
+		"'":          "Quote",
+		"f5":         "F5",
+		"pad1a":      "Pad1A",
+		"Foo":        "Foo",
+		"":           "",
+	}
+	for name, want := range tests {
+		if got := NormalizeKeyName(name); got != want {
+			t.Errorf("NormalizeKeyName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestInputMapperLayouts(t *testing.T) {
+	tests := []struct {
+		layout, name string
+		want         chip8.Key
+	}{
+		{LayoutQWERTY, "q", chip8.Key4},
+		{LayoutQWERTY, "v", chip8.KeyF},
+		{LayoutAZERTY, "a", chip8.Key4},
+		{LayoutAZERTY, "w", chip8.KeyA},
+		{LayoutDvorak, ",", chip8.Key5},
+		{LayoutDvorak, "k", chip8.KeyF},
+		{LayoutNumpad, "Keypad 7", chip8.Key7},
+		{LayoutNumpad, "NumpadEnter", chip8.KeyE},
+	}
+	for _, tt := range tests {
+		m, err := NewInputMapper(tt.layout, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var keypad chip8.Keypad
+		m.Handle(tt.name, true)
+		m.Apply(&keypad)
+		if !keypad.IsPressed(tt.want) {
+			t.Errorf("%s %q did not press %X", tt.layout, tt.name, tt.want)
+		}
+	}
+
+	if _, err := NewInputMapper("colemak", nil, nil); err == nil {
+		t.Error("unknown layout should be an error")
+	}
+}
+
+func TestInputMapperBindings(t *testing.T) {
+	m, err := NewInputMapper(LayoutQWERTY, map[string]chip8.Key{"k": chip8.Key5}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// W and K both press 5; it is released when both are.
+	var keypad chip8.Keypad
+	m.Handle("W", true)
+	m.Handle("K", true)
+	m.Apply(&keypad)
+	m.Handle("W", false)
+	m.Apply(&keypad)
+	if !keypad.IsPressed(chip8.Key5) {
+		t.Error("5 released while K is held")
+	}
+	m.Handle("K", false)
+	m.Apply(&keypad)
+	if keypad.IsPressed(chip8.Key5) {
+		t.Error("5 held after both keys were released")
+	}
+
+	// Keys pressed by other means are left alone.
+	keypad.Press(chip8.KeyF)
+	m.Apply(&keypad)
+	if !keypad.IsPressed(chip8.KeyF) {
+		t.Error("Apply released a key it did not press")
+	}
+
+	// The ROM override replaces the user's binding.
+	m.bindROM(nil, map[string]chip8.Key{"K": chip8.Key6})
+	m.Handle("K", true)
+	m.Apply(&keypad)
+	if !keypad.IsPressed(chip8.Key6) || keypad.IsPressed(chip8.Key5) {
+		t.Error("override binding of K not used")
+	}
+	if !m.Bound("w") || m.Bound("Tab") {
+		t.Error("Bound reports the wrong keys")
+	}
+}
+
+func TestInputMapperTurbo(t *testing.T) {
+	m, err := NewInputMapper(LayoutQWERTY, nil, map[string]chip8.Key{"Space": chip8.Key6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.TurboRate = 15 // 2 frames down, 2 up
+
+	var keypad chip8.Keypad
+	m.Handle("Space", true)
+	var got []bool
+	for range 6 {
+		m.Apply(&keypad)
+		got = append(got, keypad.IsPressed(chip8.Key6))
+	}
+	want := []bool{true, true, false, false, true, true}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("turbo frames = %v, want %v", got, want)
+		}
+	}
+
+	m.Handle("Space", false)
+	m.Apply(&keypad)
+	if keypad.IsPressed(chip8.Key6) {
+		t.Error("turbo key held after release")
+	}
+}
+
+func TestInputMapperROM(t *testing.T) {
+	rom, err := os.ReadFile("../../testdata/roms/gamepack-chip8/PONG2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	emu, _ := NewEmu()
+	emu.Input, _ = NewInputMapper(LayoutQWERTY, nil, nil)
+	if _, err := emu.LoadROM(rom, ".ch8"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pong 2 lists up 1 for player 1 and up C for player 2.
+	emu.Input.Handle("Up", true)
+	emu.Input.Handle("Pad2Up", true)
+	emu.RunFrame()
+	if !emu.VM.Keypad.IsPressed(chip8.Key1) || !emu.VM.Keypad.IsPressed(chip8.KeyC) {
+		t.Error("arrow key and second gamepad not mapped from the ROM's keys")
+	}
+}
@@ -0,0 +1,369 @@
+package host
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+	"github.com/mxmgorin/ch8go/pkg/db"
+)
+
+// libraryKey is the Store key of the library cache.
+const libraryKey = "library.json"
+
+// maxROMSize is the largest program that fits in memory.
+const maxROMSize = chip8.MemorySize - chip8.ProgramStart
+
+// How a LibraryEntry was identified.
+const (
+	MatchHash = "hash" // SHA-1 found in the MetaDB
+	MatchFile = "file" // file name matches a MetaDB ROM
+)
+
+// LibraryEntry is a ROM found by Library.Scan.
+type LibraryEntry struct {
+	Path    string    `json:"path"`             // file on disk
+	Member  string    `json:"member,omitempty"` // ROM inside the zip file at Path
+	Size    int64     `json:"size"`             // of the file at Path
+	ModTime time.Time `json:"modTime"`          // of the file at Path
+	Hash    string    `json:"hash"`
+
+	// Filled in from the MetaDB on every scan; not cached.
+	Match      string    `json:"-"` // MatchHash, MatchFile or empty if unknown
+	Title      string    `json:"-"` // the file name for unknown ROMs
+	Authors    []string  `json:"-"`
+	Platform   string    `json:"-"` // database platform id, or the extension's platform
+	Release    string    `json:"-"`
+	LastPlayed time.Time `json:"-"` // zero if never played
+}
+
+// Name returns the file name of the ROM.
+func (e *LibraryEntry) Name() string {
+	if e.Member != "" {
+		return path.Base(e.Member)
+	}
+	return filepath.Base(e.Path)
+}
+
+// Ext returns the lowercased extension of the ROM file name.
+func (e *LibraryEntry) Ext() string {
+	return strings.ToLower(filepath.Ext(e.Name()))
+}
+
+// Library catalogs the ROMs in a set of directories, including those inside
+// zip files, by title. Files with a ROM extension such as .ch8 are always
+// listed; files without an extension only when the MetaDB knows their hash.
+// Hashes and last played times are cached in Store so rescans only read
+// changed files.
+type Library struct {
+	Dirs    []string
+	MetaDB  *db.MetaDB
+	Store   Store // optional
+	entries []LibraryEntry
+	played  map[string]time.Time // hash → last played
+}
+
+type libraryCache struct {
+	Entries []LibraryEntry       `json:"entries"`
+	Played  map[string]time.Time `json:"played,omitempty"`
+}
+
+func NewLibrary(metaDB *db.MetaDB, store Store, dirs ...string) *Library {
+	return &Library{Dirs: dirs, MetaDB: metaDB, Store: store, played: map[string]time.Time{}}
+}
+
+// Entries returns the catalog ordered by title, then path.
+func (l *Library) Entries() []LibraryEntry {
+	return l.entries
+}
+
+// Scan walks Dirs and rebuilds the catalog. Unreadable files are skipped
+// and reported in the returned error; the rest of the catalog is kept.
+func (l *Library) Scan() error {
+	cache := l.loadCache()
+	cached := map[string][]LibraryEntry{}
+	for _, e := range cache.Entries {
+		cached[e.Path] = append(cached[e.Path], e)
+	}
+
+	var entries []LibraryEntry
+	var errs []error
+	for _, dir := range l.Dirs {
+		err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				errs = append(errs, err)
+				return nil
+			}
+			if d.IsDir() || !l.candidate(d.Name()) {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				errs = append(errs, err)
+				return nil
+			}
+
+			if old := cached[p]; len(old) > 0 && old[0].Size == info.Size() && old[0].ModTime.Equal(info.ModTime()) {
+				entries = append(entries, old...)
+				return nil
+			}
+
+			found, err := scanFile(p, info)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			entries = append(entries, found...)
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	kept := entries[:0]
+	for _, e := range entries {
+		if l.describe(&e) {
+			kept = append(kept, e)
+		}
+	}
+	entries = kept
+
+	slices.SortFunc(entries, func(a, b LibraryEntry) int {
+		if c := strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title)); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Path, b.Path); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Member, b.Member)
+	})
+
+	l.entries = entries
+	if err := l.saveCache(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// candidate reports whether a file may hold ROMs.
+func (l *Library) candidate(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	_, rom := chip8.PlatformByExt[ext]
+	return rom || ext == ".zip" || ext == ""
+}
+
+// scanFile hashes the ROM at p, or each ROM inside it if it is a zip file.
+func scanFile(p string, info fs.FileInfo) ([]LibraryEntry, error) {
+	entry := LibraryEntry{Path: p, Size: info.Size(), ModTime: info.ModTime()}
+
+	if strings.ToLower(filepath.Ext(p)) != ".zip" {
+		if info.Size() > maxROMSize {
+			return nil, nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		entry.Hash = db.SHA1Of(data)
+		return []LibraryEntry{entry}, nil
+	}
+
+	zr, err := zip.OpenReader(p)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+	defer zr.Close()
+
+	var entries []LibraryEntry
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || f.UncompressedSize64 > maxROMSize {
+			continue
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return entries, fmt.Errorf("%s: %s: %w", p, f.Name, err)
+		}
+		e := entry
+		e.Member = f.Name
+		e.Hash = db.SHA1Of(data)
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, maxROMSize+1))
+}
+
+// describe fills in the catalog fields of e from the MetaDB and reports
+// whether e belongs in the catalog.
+func (l *Library) describe(e *LibraryEntry) bool {
+	ext := e.Ext()
+	_, romExt := chip8.PlatformByExt[ext]
+
+	e.Match, e.Authors, e.Release, e.Platform = "", nil, "", ""
+	e.Title = strings.TrimSuffix(e.Name(), filepath.Ext(e.Name()))
+	e.LastPlayed = l.played[e.Hash]
+
+	hash := e.Hash
+	if l.MetaDB.Program(hash) != nil {
+		e.Match = MatchHash
+	} else if h, ok := l.MetaDB.HashByFile(e.Name()); ok && romExt {
+		e.Match = MatchFile
+		hash = h
+	}
+
+	if e.Match == "" {
+		if p, ok := chip8.PlatformByExt[ext]; ok {
+			e.Platform = string(p)
+		}
+		return romExt
+	}
+
+	p := l.MetaDB.Program(hash)
+	rom := l.MetaDB.ROM(hash)
+	e.Title, e.Authors, e.Release = p.Title, p.Authors, p.Release
+	if ids := rom.PlatformIDs(); len(ids) > 0 {
+		e.Platform = ids[0]
+	}
+	return true
+}
+
+// Lookup finds the entry titled title, ignoring case, or else the first
+// whose title contains it.
+func (l *Library) Lookup(title string) (LibraryEntry, bool) {
+	title = strings.ToLower(title)
+	for _, e := range l.entries {
+		if strings.ToLower(e.Title) == title {
+			return e, true
+		}
+	}
+	for _, e := range l.entries {
+		if strings.Contains(strings.ToLower(e.Title), title) {
+			return e, true
+		}
+	}
+	return LibraryEntry{}, false
+}
+
+// Read returns the ROM of e.
+func (l *Library) Read(e LibraryEntry) ([]byte, error) {
+	if e.Member == "" {
+		return os.ReadFile(e.Path)
+	}
+
+	zr, err := zip.OpenReader(e.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if f.Name == e.Member {
+			return readZipFile(f)
+		}
+	}
+	return nil, fmt.Errorf("%s: %s not found", e.Path, e.Member)
+}
+
+// MarkPlayed records t as the last time the ROM with the given hash was
+// played.
+func (l *Library) MarkPlayed(hash string, t time.Time) error {
+	l.played[hash] = t
+	for i := range l.entries {
+		if l.entries[i].Hash == hash {
+			l.entries[i].LastPlayed = t
+		}
+	}
+	return l.saveCache()
+}
+
+func (l *Library) loadCache() libraryCache {
+	var c libraryCache
+	if l.Store == nil {
+		return c
+	}
+
+	data, err := l.Store.Load(libraryKey)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil {
+		slog.Warn("Ignoring library cache:", "err", err)
+		return libraryCache{}
+	}
+
+	for h, t := range c.Played {
+		if _, ok := l.played[h]; !ok {
+			l.played[h] = t
+		}
+	}
+	return c
+}
+
+func (l *Library) saveCache() error {
+	if l.Store == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(libraryCache{Entries: l.entries, Played: l.played})
+	if err != nil {
+		return err
+	}
+	return l.Store.Save(libraryKey, data)
+}
+
+// OpenROM loads the ROM file at name or, if there is no such file, the
+// Library entry titled name.
+func (e *Emu) OpenROM(name string) (int, error) {
+	_, err := os.Stat(name)
+	if err == nil || e.Library == nil || !errors.Is(err, fs.ErrNotExist) {
+		return e.ReadROM(name)
+	}
+
+	entry, ok := e.Library.Lookup(name)
+	if !ok {
+		return 0, fmt.Errorf("%s: no such file or library title", name)
+	}
+	return e.LoadEntry(entry)
+}
+
+// LoadEntry loads a ROM of the Library.
+func (e *Emu) LoadEntry(entry LibraryEntry) (int, error) {
+	data, err := e.Library.Read(entry)
+	if err != nil {
+		return 0, err
+	}
+	slog.Info("Library:", "title", entry.Title, "path", entry.Path, "member", entry.Member)
+
+	var opts *OctoOptions
+	p := entry.Path
+	if entry.Member == "" {
+		if opts, err = ReadSidecar(entry.Path); err != nil {
+			return 0, err
+		}
+	} else {
+		p = zipMemberPath(entry.Path, entry.Member)
+	}
+	return e.loadPatched(data, p, entry.Ext(), opts)
+}
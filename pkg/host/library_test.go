@@ -0,0 +1,182 @@
+package host
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+	"github.com/mxmgorin/ch8go/pkg/db"
+)
+
+func TestLibraryScan(t *testing.T) {
+	dir := t.TempDir()
+	track, err := os.ReadFile("../../testdata/roms/chip8archive/xo/superOctoTrackXO.ch8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tetris, err := os.ReadFile("../../testdata/roms/gamepack-chip8/TETRIS")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(dir, "track.ch8"), track)
+	writeFile(t, filepath.Join(dir, "sub", "skyward.ch8"), []byte{0x12, 0x00}) // matched by name only
+	writeFile(t, filepath.Join(dir, "mine.sc8"), []byte{0x12, 0x02})
+	writeFile(t, filepath.Join(dir, "NOTES"), []byte("not a ROM"))
+	writeFile(t, filepath.Join(dir, "readme.txt"), []byte("ignored"))
+	writeZip(t, filepath.Join(dir, "pack.zip"), map[string][]byte{"games/TETRIS": tetris, "info.txt": []byte("x")})
+
+	emu, _ := NewEmu()
+	store := DirStore(t.TempDir())
+	lib := NewLibrary(emu.MetaDB, store, dir)
+	if err := lib.Scan(); err != nil {
+		t.Fatal(err)
+	}
+
+	byName := map[string]LibraryEntry{}
+	for _, e := range lib.Entries() {
+		byName[e.Name()] = e
+	}
+	if len(byName) != 4 {
+		t.Fatalf("Entries = %+v, want 4 ROMs", lib.Entries())
+	}
+
+	tests := []struct {
+		name, title, match, platform string
+	}{
+		{"track.ch8", "Super Octo Track XO", MatchHash, "xochip"},
+		{"skyward.ch8", "Skyward", MatchFile, "xochip"},
+		{"mine.sc8", "mine", "", "sc"},
+		{"TETRIS", "Tetris", MatchHash, "chip48"},
+	}
+	for _, tt := range tests {
+		e := byName[tt.name]
+		if e.Title != tt.title || e.Match != tt.match || e.Platform != tt.platform {
+			t.Errorf("%s = {%q %q %q}, want {%q %q %q}", tt.name, e.Title, e.Match, e.Platform, tt.title, tt.match, tt.platform)
+		}
+	}
+	if e := byName["TETRIS"]; e.Member != "games/TETRIS" {
+		t.Errorf("zip member = %q", e.Member)
+	}
+
+	// A rescan trusts the cache for files whose size and time are unchanged.
+	path := filepath.Join(dir, "mine.sc8")
+	info, _ := os.Stat(path)
+	writeFile(t, path, []byte{0x12, 0x04})
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	lib = NewLibrary(emu.MetaDB, store, dir)
+	if err := lib.Scan(); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := lib.Lookup("mine"); e.Hash != byName["mine.sc8"].Hash {
+		t.Errorf("cached hash not used: %s", e.Hash)
+	}
+}
+
+func TestOpenROMFromLibrary(t *testing.T) {
+	dir := t.TempDir()
+	tetris, err := os.ReadFile("../../testdata/roms/gamepack-chip8/TETRIS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeZip(t, filepath.Join(dir, "pack.zip"), map[string][]byte{"TETRIS": tetris})
+
+	emu, _ := NewEmu()
+	emu.Library = NewLibrary(emu.MetaDB, DirStore(t.TempDir()), dir)
+	if err := emu.Library.Scan(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := emu.OpenROM("tetris"); err != nil {
+		t.Fatal(err)
+	}
+	if p := emu.MetaDB.Program(emu.ROMHash); p == nil || p.Title != "Tetris" {
+		t.Errorf("loaded %s, want Tetris", emu.ROMHash)
+	}
+	if e, _ := emu.Library.Lookup("Tetris"); e.LastPlayed.IsZero() {
+		t.Error("LastPlayed not set by LoadROM")
+	}
+
+	if _, err := emu.OpenROM("no such game"); err == nil {
+		t.Error("OpenROM of an unknown title should fail")
+	}
+}
+
+func TestLoadROMFailureKeepsState(t *testing.T) {
+	emu, _ := NewEmu()
+	emu.Library = NewLibrary(emu.MetaDB, DirStore(t.TempDir()))
+	if _, err := emu.LoadROM([]byte{0x12, 0x00}, ".ch8"); err != nil {
+		t.Fatal(err)
+	}
+	hash := emu.ROMHash
+
+	big := make([]byte, chip8.MemorySize)
+	if _, err := emu.LoadROM(big, ".ch8"); err == nil {
+		t.Fatal("LoadROM of an oversized ROM should fail")
+	}
+	if emu.ROMHash != hash {
+		t.Errorf("ROMHash = %s after a failed load, want %s", emu.ROMHash, hash)
+	}
+	if _, ok := emu.Library.played[db.SHA1Of(big)]; ok {
+		t.Error("a ROM that failed to load was marked as played")
+	}
+}
+
+func TestLoadEntryPatched(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "game.ch8"), []byte{0x12, 0x00, 0x00})
+	writeFile(t, filepath.Join(dir, "game.ips"), []byte("PATCH\x00\x00\x01\x00\x02\x02\xAAEOF"))
+
+	emu, _ := NewEmu()
+	emu.Library = NewLibrary(emu.MetaDB, DirStore(t.TempDir()), dir)
+	if err := emu.Library.Scan(); err != nil {
+		t.Fatal(err)
+	}
+	entry := emu.Library.Entries()[0]
+	if _, err := emu.LoadEntry(entry); err != nil {
+		t.Fatal(err)
+	}
+	if emu.ROMHash == entry.Hash {
+		t.Fatal("ROM not patched")
+	}
+	if e := emu.Library.Entries()[0]; e.LastPlayed.IsZero() {
+		t.Error("LastPlayed of the patched entry not set")
+	}
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeZip(t *testing.T, path string, files map[string][]byte) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,222 @@
+package host
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+)
+
+// OctoOptions are the emulator settings Octo keeps with a program, as found
+// in cartridges, Octo's options JSON and sidecar files next to ROMs. Unset
+// fields leave the configuration they are applied to unchanged.
+type OctoOptions struct {
+	Tickrate        int      `json:"tickrate,omitempty"`
+	FillColor       string   `json:"fillColor,omitempty"`
+	FillColor2      string   `json:"fillColor2,omitempty"`
+	BlendColor      string   `json:"blendColor,omitempty"`
+	BackgroundColor string   `json:"backgroundColor,omitempty"`
+	BuzzColor       string   `json:"buzzColor,omitempty"`
+	QuietColor      string   `json:"quietColor,omitempty"`
+	ShiftQuirks     *bool    `json:"shiftQuirks,omitempty"`
+	LoadStoreQuirks *bool    `json:"loadStoreQuirks,omitempty"`
+	VFOrderQuirks   *bool    `json:"vfOrderQuirks,omitempty"` // not emulated
+	ClipQuirks      *bool    `json:"clipQuirks,omitempty"`
+	VBlankQuirks    *bool    `json:"vBlankQuirks,omitempty"`
+	JumpQuirks      *bool    `json:"jumpQuirks,omitempty"`
+	LogicQuirks     *bool    `json:"logicQuirks,omitempty"`
+	ScreenRotation  *int     `json:"screenRotation,omitempty"`
+	MaxSize         octoSize `json:"maxSize,omitempty"`        // program size limit, kept as is
+	TouchInputMode  string   `json:"touchInputMode,omitempty"` // kept as is
+	FontStyle       string   `json:"fontStyle,omitempty"`
+}
+
+// octoSize is a number Octo writes either plainly or as a string.
+type octoSize int
+
+func (s *octoSize) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case float64:
+		*s = octoSize(v)
+	case string:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid maxSize %q", v)
+		}
+		*s = octoSize(n)
+	case nil:
+		*s = 0
+	default:
+		return fmt.Errorf("invalid maxSize %v", v)
+	}
+	return nil
+}
+
+// octoQuirk pairs an Octo quirk option with the database quirk it sets.
+type octoQuirk struct {
+	name   string
+	option func(o *OctoOptions) **bool
+	invert bool // clipQuirks is the opposite of wrap
+}
+
+// octoQuirks maps the Octo quirk options. Octo has no counterpart for
+// memoryIncrementByX and scaleScroll.
+var octoQuirks = []octoQuirk{
+	{"shift", func(o *OctoOptions) **bool { return &o.ShiftQuirks }, false},
+	{"memoryLeaveIUnchanged", func(o *OctoOptions) **bool { return &o.LoadStoreQuirks }, false},
+	{"wrap", func(o *OctoOptions) **bool { return &o.ClipQuirks }, true},
+	{"vblank", func(o *OctoOptions) **bool { return &o.VBlankQuirks }, false},
+	{"jump", func(o *OctoOptions) **bool { return &o.JumpQuirks }, false},
+	{"logic", func(o *OctoOptions) **bool { return &o.LogicQuirks }, false},
+}
+
+// NewOctoOptions converts a configuration, the first four colors of pal and
+// a display rotation to Octo options.
+func NewOctoOptions(conf chip8.PlatformConf, pal *Palette, rotation int) OctoOptions {
+	o := OctoOptions{
+		Tickrate:        conf.Tickrate,
+		BackgroundColor: pal.Pixels[0].ToHex(),
+		FillColor:       pal.Pixels[1].ToHex(),
+		FillColor2:      pal.Pixels[2].ToHex(),
+		BlendColor:      pal.Pixels[3].ToHex(),
+		BuzzColor:       pal.Buzzer.ToHex(),
+		QuietColor:      pal.Silence.ToHex(),
+		VFOrderQuirks:   new(bool),
+		ScreenRotation:  &rotation,
+		MaxSize:         octoSize(chip8.MemorySize - chip8.ProgramStart),
+		FontStyle:       string(conf.Font),
+	}
+	if conf.AudioMode != chip8.AudioXOChip {
+		o.MaxSize = 0x1000 - chip8.ProgramStart
+	}
+	if o.FontStyle == "" {
+		o.FontStyle = string(chip8.FontSChip)
+	}
+
+	for _, q := range octoQuirks {
+		v := *QuirkField(&conf.Quirks, q.name) != q.invert
+		*q.option(&o) = &v
+	}
+	return o
+}
+
+// Quirks returns the quirks set by o by their database names.
+func (o *OctoOptions) Quirks() map[string]bool {
+	quirks := map[string]bool{}
+	for _, q := range octoQuirks {
+		if v := *q.option(o); v != nil {
+			quirks[q.name] = *v != q.invert
+		}
+	}
+	return quirks
+}
+
+// Conf returns base with the quirks, tickrate and font of o.
+func (o *OctoOptions) Conf(base chip8.PlatformConf) chip8.PlatformConf {
+	o.applyConf(&base, QuirkSources{})
+	return base
+}
+
+// Palette returns base with the colors of o.
+func (o *OctoOptions) Palette(base Palette) (Palette, error) {
+	err := o.applyPalette(&base)
+	return base, err
+}
+
+// colors returns the pixel colors of o, background first, with empty
+// strings for those it leaves unset.
+func (o *OctoOptions) colors() []string {
+	return []string{o.BackgroundColor, o.FillColor, o.FillColor2, o.BlendColor}
+}
+
+// Validate checks the colors and rotation. Unknown font styles are not an
+// error; LoadROM falls back to the default font.
+func (o *OctoOptions) Validate() error {
+	p := DefaultPalette
+	if err := o.applyPalette(&p); err != nil {
+		return err
+	}
+
+	if o.ScreenRotation != nil {
+		switch *o.ScreenRotation {
+		case 0, 90, 180, 270:
+		default:
+			return fmt.Errorf("invalid screenRotation %d (use 0, 90, 180 or 270)", *o.ScreenRotation)
+		}
+	}
+
+	if o.Tickrate < 0 {
+		return fmt.Errorf("invalid tickrate %d", o.Tickrate)
+	}
+	return nil
+}
+
+func (o *OctoOptions) applyPalette(p *Palette) error {
+	for i, hex := range o.colors() {
+		if hex == "" {
+			continue
+		}
+		if err := p.SetColor(i, hex); err != nil {
+			return fmt.Errorf("palette: %w", err)
+		}
+	}
+
+	for _, c := range []struct {
+		hex   string
+		color *Color
+	}{{o.BuzzColor, &p.Buzzer}, {o.QuietColor, &p.Silence}} {
+		if c.hex == "" {
+			continue
+		}
+		color, err := ParseHexColor(c.hex)
+		if err != nil {
+			return fmt.Errorf("palette: %w", err)
+		}
+		*c.color = color
+	}
+
+	return nil
+}
+
+// applyConf sets the quirks, tickrate and font of o on conf, recording
+// "octo options" as the source of each quirk.
+func (o *OctoOptions) applyConf(conf *chip8.PlatformConf, sources QuirkSources) {
+	for name, v := range o.Quirks() {
+		*QuirkField(&conf.Quirks, name) = v
+		sources[name] = "octo options"
+	}
+	if o.VFOrderQuirks != nil && *o.VFOrderQuirks {
+		slog.Warn("Unsupported Octo option:", "vfOrderQuirks", true)
+	}
+
+	if o.Tickrate > 0 {
+		conf.Tickrate = o.Tickrate
+	}
+
+	if font := chip8.FontStyle(o.FontStyle); chip8.ValidFont(font) {
+		if font != "" {
+			conf.Font = font
+		}
+	} else {
+		slog.Warn("Unsupported font style:", "font", font)
+	}
+}
+
+// OctoOptions returns the current configuration as Octo options, e.g. to
+// save as a sidecar file.
+func (e *Emu) OctoOptions() OctoOptions {
+	o := NewOctoOptions(e.VM.Conf(), &e.Palette, e.Rotation())
+	if e.ROMOptions != nil && e.ROMOptions.TouchInputMode != "" {
+		o.TouchInputMode = e.ROMOptions.TouchInputMode
+	} else if rm := e.ROMMeta(); rm != nil {
+		o.TouchInputMode = rm.TouchInputMode
+	}
+	return o
+}
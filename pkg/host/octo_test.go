@@ -0,0 +1,115 @@
+package host
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+)
+
+func boolPtr(v bool) *bool { return &v }
+
+func intPtr(v int) *int { return &v }
+
+func TestOctoOptionsRoundTrip(t *testing.T) {
+	conf := chip8.ConfByPlatform[chip8.PlatformXOChip]
+	conf.Tickrate = 500
+	conf.Font = chip8.FontOcto
+	conf.Quirks.Jump = true
+	pal := DefaultPalette
+	_ = pal.SetColor(2, "#123456")
+
+	o := NewOctoOptions(conf, &pal, 270)
+	data, err := json.Marshal(&o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got OctoOptions
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.MaxSize != 65024 || got.ScreenRotation == nil || *got.ScreenRotation != 270 || got.FillColor2 != "#123456" {
+		t.Errorf("options = %s", data)
+	}
+
+	// Octo has no memoryIncrementByX and scaleScroll; they keep the base's.
+	base := chip8.ConfByPlatform[chip8.PlatformChip8]
+	base.Quirks.MemIncIByX = conf.Quirks.MemIncIByX
+	base.Quirks.ScaleScroll = conf.Quirks.ScaleScroll
+	if c := got.Conf(base); c.Quirks != conf.Quirks || c.Tickrate != 500 || c.Font != chip8.FontOcto {
+		t.Errorf("Conf() = %+v, want %+v", c, conf)
+	}
+
+	p, err := got.Palette(DefaultPalette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if [4]Color(p.Pixels[:4]) != [4]Color(pal.Pixels[:4]) || p.Buzzer != pal.Buzzer || p.Silence != pal.Silence {
+		t.Errorf("Palette() = %v, want %v", p, pal)
+	}
+}
+
+func TestOctoOptionsZeroRotation(t *testing.T) {
+	emu, _ := NewEmu()
+	// The database shows Sub-8 rotated by 270 degrees.
+	rom, err := os.ReadFile("../../testdata/roms/chip8archive/sc/sub8.ch8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := emu.LoadROM(rom, ".ch8"); err != nil {
+		t.Fatal(err)
+	}
+	if err := emu.SetRotation(0); err != nil {
+		t.Fatal(err)
+	}
+
+	exported := emu.OctoOptions()
+	data, err := json.Marshal(&exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var o OctoOptions
+	if err := json.Unmarshal(data, &o); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := emu.LoadROMWithOptions(rom, ".ch8", &o); err != nil {
+		t.Fatal(err)
+	}
+	if got := emu.Rotation(); got != 0 {
+		t.Errorf("Rotation = %d, want the exported 0 over the database: %s", got, data)
+	}
+}
+
+func TestSidecarOptions(t *testing.T) {
+	dir := t.TempDir()
+	rom := filepath.Join(dir, "game.ch8")
+	writeFile(t, rom, []byte{0x12, 0x00})
+	writeFile(t, SidecarPath(rom), []byte(`{"tickrate": 20, "vBlankQuirks": true, "maxSize": "3584", "backgroundColor": "#102030"}`))
+
+	emu, _ := NewEmu()
+	if _, err := emu.ReadROM(rom); err != nil {
+		t.Fatal(err)
+	}
+	chip8Conf := chip8.ConfByPlatform[chip8.PlatformChip8]
+	want := chip8Conf.Quirks
+	want.WaitVBlank = true
+	if emu.VM.Tickrate() != 20 || emu.VM.CPU.Quirks != want {
+		t.Errorf("tickrate %d, quirks %+v, want the sidecar over chip8", emu.VM.Tickrate(), emu.VM.CPU.Quirks)
+	}
+	if emu.QuirkSources["vblank"] != "octo options" || emu.QuirkSources["shift"] != "extension .ch8" {
+		t.Errorf("sources = %v", emu.QuirkSources)
+	}
+
+	o := emu.OctoOptions()
+	if o.Tickrate != 20 || o.BackgroundColor != "#102030" || o.VBlankQuirks == nil || !*o.VBlankQuirks {
+		t.Errorf("OctoOptions() = %+v", o)
+	}
+
+	writeFile(t, SidecarPath(rom), []byte(`{"screenRotation": 45}`))
+	if _, err := emu.ReadROM(rom); err == nil {
+		t.Error("ReadROM with an invalid sidecar: expected an error")
+	}
+}
@@ -1,14 +1,38 @@
 package host
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+	"github.com/mxmgorin/ch8go/pkg/db"
 )
 
 // Options contains command-line configuration used to run the emulator on the host system.
 type Options struct {
 	ROMPath string
+	Config  string // config file the defaults below were read from
 	Scale   int
+	Volume  int // percent, 0-100
+	Mute    bool
+	// NoSaveFlags keeps RPL user flags (FX75/FX85) in memory only instead
+	// of saving them per ROM.
+	NoSaveFlags bool
+	Palette     string // comma-separated hex colors, background first
+	Keymap      map[string]chip8.Key
+	Turbo       map[string]chip8.Key // host keys that auto-fire their CHIP-8 key
+	TurboRate   int                  // turbo presses per second
+	Layout      string               // keyboard layout, see KeyLayouts
+	Filter      string
+	Platform    string // default platform for unidentified ROMs
+	Tickrate    int
+	Quirks      string
+	DBDir       string // database directory loaded on top of the embedded one
+	DBReplace   bool   // DBDir replaces the embedded database
+	Library     string // comma-separated ROM directories scanned into Emu.Library
 }
 
 func (o *Options) ValidateROMPath() error {
@@ -18,16 +42,238 @@ func (o *Options) ValidateROMPath() error {
 	return nil
 }
 
-// ParseOptions parses emulator-related command-line flags from args.
+// ParseOptions parses emulator-related command-line flags from args and
+// fills in the values not given on the command line from the config file.
 func ParseOptions(fs *flag.FlagSet, args []string) (Options, error) {
+	return ParseOptionsWithConfig(fs, args, DefaultConfigPath())
+}
+
+// ParseOptionsWithConfig is ParseOptions reading defaultConfig when no
+// --config flag is given. An empty defaultConfig reads no config file
+// unless --config names one.
+func ParseOptionsWithConfig(fs *flag.FlagSet, args []string, defaultConfig string) (Options, error) {
 	opts := Options{}
 
 	fs.StringVar(&opts.ROMPath, "rom", "", "path to CHIP-8 ROM")
+	fs.StringVar(&opts.Config, "config", defaultConfig, "JSON config file with user defaults; empty skips it")
 	fs.IntVar(&opts.Scale, "scale", 12, "window scale")
+	fs.IntVar(&opts.Volume, "volume", int(DefaultVolume*100), "audio volume in percent (0-100)")
+	fs.BoolVar(&opts.Mute, "mute", false, "start with audio muted")
+	fs.BoolVar(&opts.NoSaveFlags, "no-save-flags", false, "do not save RPL user flags (high scores) per ROM")
+	fs.StringVar(&opts.Palette, "palette", "", "comma-separated hex colors, background first")
+	fs.IntVar(&opts.TurboRate, "turbo-rate", 0, "turbo presses per second (1-30)")
+	fs.StringVar(&opts.Layout, "layout", LayoutQWERTY, "keyboard layout: "+strings.Join(Layouts(), ", "))
+	fs.StringVar(&opts.Filter, "filter", FilterNearest, "scaling filter: nearest or linear")
+	fs.StringVar(&opts.Platform, "default-platform", "", "platform for unidentified ROMs: ch8, sc or xo")
+	fs.IntVar(&opts.Tickrate, "tickrate", 0, "default instructions per frame for ROMs the database has none for")
+	fs.StringVar(&opts.Quirks, "quirks", "", "quirk defaults below the database, e.g. shift=1,vblank=0")
+	fs.StringVar(&opts.DBDir, "db", "", "CHIP-8 database directory to merge into the embedded one")
+	fs.BoolVar(&opts.DBReplace, "db-replace", false, "use the --db directory instead of the embedded database")
+	fs.StringVar(&opts.Library, "library", "", "comma-separated ROM directories; --rom may then name a title")
 
 	if err := fs.Parse(args); err != nil {
 		return opts, err
 	}
 
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	conf, err := LoadConfig(opts.Config, set["config"])
+	if err != nil {
+		return opts, err
+	}
+	if err := opts.merge(&conf, set); err != nil {
+		return opts, fmt.Errorf("%s: %w", opts.Config, err)
+	}
+
+	if err := opts.validate(); err != nil {
+		return opts, err
+	}
+
 	return opts, nil
 }
+
+// merge copies config values for the flags that were not set.
+func (o *Options) merge(c *Config, set map[string]bool) error {
+	if c.Scale > 0 && !set["scale"] {
+		o.Scale = c.Scale
+	}
+	if c.Volume != nil && !set["volume"] {
+		o.Volume = *c.Volume
+	}
+	if c.Mute && !set["mute"] {
+		o.Mute = true
+	}
+	if len(c.Palette) > 0 && !set["palette"] {
+		o.Palette = strings.Join(c.Palette, ",")
+	}
+	if c.Filter != "" && !set["filter"] {
+		o.Filter = c.Filter
+	}
+	if c.Layout != "" && !set["layout"] {
+		o.Layout = c.Layout
+	}
+	if c.TurboRate > 0 && !set["turbo-rate"] {
+		o.TurboRate = c.TurboRate
+	}
+	if c.Platform != "" && !set["default-platform"] {
+		o.Platform = c.Platform
+	}
+	if c.Tickrate > 0 && !set["tickrate"] {
+		o.Tickrate = c.Tickrate
+	}
+	if c.Database != "" && !set["db"] {
+		o.DBDir = c.Database
+	}
+	if c.DatabaseReplace && !set["db-replace"] {
+		o.DBReplace = true
+	}
+	if len(c.Library) > 0 && !set["library"] {
+		o.Library = strings.Join(c.Library, ",")
+	}
+	if spec := c.quirkSpec(); spec != "" {
+		// Flag overrides come last so they win.
+		o.Quirks = strings.Trim(spec+","+o.Quirks, ",")
+	}
+
+	o.Keymap = map[string]chip8.Key{}
+	for name, key := range c.Keymap {
+		if name == "" {
+			return errors.New("keymap: empty key name")
+		}
+		k, err := ParseKey(key)
+		if err != nil {
+			return fmt.Errorf("keymap %q: %w", name, err)
+		}
+		o.Keymap[name] = k
+	}
+
+	o.Turbo = map[string]chip8.Key{}
+	for name, key := range c.Turbo {
+		if name == "" {
+			return errors.New("turbo: empty key name")
+		}
+		k, err := ParseKey(key)
+		if err != nil {
+			return fmt.Errorf("turbo %q: %w", name, err)
+		}
+		o.Turbo[name] = k
+	}
+
+	return nil
+}
+
+func (o *Options) validate() error {
+	if o.Volume < 0 || o.Volume > 100 {
+		return fmt.Errorf("invalid volume %d (use 0-100)", o.Volume)
+	}
+	if o.Filter != FilterNearest && o.Filter != FilterLinear {
+		return fmt.Errorf("invalid filter %q (use %s or %s)", o.Filter, FilterNearest, FilterLinear)
+	}
+	if o.TurboRate < 0 || o.TurboRate > 30 {
+		return fmt.Errorf("invalid turbo rate %d (use 1-30)", o.TurboRate)
+	}
+	if _, err := o.InputMapper(); err != nil {
+		return err
+	}
+	if _, err := o.UserConf(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// InputMapper returns the key mapper for the layout, keymap and turbo
+// bindings, to set as Emu.Input.
+func (o *Options) InputMapper() (*InputMapper, error) {
+	m, err := NewInputMapper(o.Layout, o.Keymap, o.Turbo)
+	if err != nil {
+		return nil, err
+	}
+	m.TurboRate = o.TurboRate
+	return m, nil
+}
+
+// UserConf returns the palette, platform, tickrate and quirk defaults to
+// set as Emu.User.
+func (o *Options) UserConf() (UserConf, error) {
+	uc := UserConf{
+		Platform: chip8.Platform(o.Platform),
+		Tickrate: o.Tickrate,
+		Quirks:   o.Quirks,
+	}
+
+	if o.Platform != "" {
+		if _, ok := chip8.ConfByPlatform[uc.Platform]; !ok {
+			return uc, fmt.Errorf("unknown platform %q (use ch8, sc or xo)", o.Platform)
+		}
+	}
+
+	if o.Quirks != "" {
+		var q chip8.Quirks
+		if err := ParseQuirks(o.Quirks, &q); err != nil {
+			return uc, err
+		}
+	}
+
+	if o.Palette != "" {
+		p := DefaultPalette
+		for i, hex := range strings.Split(o.Palette, ",") {
+			if i >= len(p.Pixels) {
+				return uc, fmt.Errorf("palette has more than %d colors", len(p.Pixels))
+			}
+			if err := p.SetColor(i, strings.TrimSpace(hex)); err != nil {
+				return uc, fmt.Errorf("palette: %w", err)
+			}
+		}
+		uc.Palette = &p
+	}
+
+	return uc, nil
+}
+
+// Apply sets the user defaults, key mapper, flag persistence, database and
+// library of e. Set e.Store first so the library scan is cached.
+func (o *Options) Apply(e *Emu) error {
+	uc, err := o.UserConf()
+	if err != nil {
+		return err
+	}
+	e.User = uc
+	e.PersistFlags = !o.NoSaveFlags
+	if e.Input, err = o.InputMapper(); err != nil {
+		return err
+	}
+
+	if o.DBDir != "" {
+		metaDB, err := db.NewMetaDB(db.Source{Dir: o.DBDir, Replace: o.DBReplace})
+		if err != nil {
+			return err
+		}
+		for _, c := range metaDB.Conflicts() {
+			slog.Warn("Database conflict:", "conflict", c.String())
+		}
+		e.MetaDB = metaDB
+	}
+
+	if o.Library != "" {
+		var dirs []string
+		for _, dir := range strings.Split(o.Library, ",") {
+			if dir = strings.TrimSpace(dir); dir != "" {
+				dirs = append(dirs, dir)
+			}
+		}
+		e.Library = NewLibrary(e.MetaDB, e.Store, dirs...)
+		if err := e.Library.Scan(); err != nil {
+			slog.Warn("Library scan:", "err", err)
+		}
+		slog.Info("Library:", "roms", len(e.Library.Entries()))
+	}
+
+	return nil
+}
+
+// ApplyAudio configures s with the volume and mute flags.
+func (o *Options) ApplyAudio(s *AudioStream) {
+	s.SetVolume(float32(o.Volume) / 100)
+	s.SetMuted(o.Mute)
+}
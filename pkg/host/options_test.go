@@ -3,7 +3,11 @@ package host
 import (
 	"flag"
 	"io"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
 )
 
 func TestValidateROMPath(t *testing.T) {
@@ -16,6 +20,11 @@ func TestValidateROMPath(t *testing.T) {
 }
 
 func TestParseOptions(t *testing.T) {
+	// Keep a config file of the user running the tests out of the defaults.
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("AppData", t.TempDir())
+
 	fs := flag.NewFlagSet("test", flag.ContinueOnError)
 	opts, err := ParseOptions(fs, []string{"--rom", "game.ch8", "--scale", "5"})
 	if err != nil {
@@ -31,8 +40,8 @@ func TestParseOptions(t *testing.T) {
 	if err != nil {
 		t.Fatalf("ParseOptions defaults error = %v", err)
 	}
-	if opts.Scale != 12 || opts.ROMPath != "" {
-		t.Errorf("defaults = %+v, want {\"\" 12}", opts)
+	if opts.Scale != 12 || opts.ROMPath != "" || opts.Volume != 50 || opts.Mute {
+		t.Errorf("defaults = %+v, want {\"\" 12 50 false}", opts)
 	}
 
 	// An unknown flag surfaces a parse error.
@@ -42,3 +51,138 @@ func TestParseOptions(t *testing.T) {
 		t.Error("unknown flag should produce an error")
 	}
 }
+
+func TestParseOptionsConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	conf := `{
+		"scale": 6,
+		"volume": 0,
+		"palette": ["#102030", "#ffffff"],
+		"keymap": {"Up": "5"},
+		"turboRate": 20,
+		"filter": "linear",
+		"platform": "xo",
+		"tickrate": 100,
+		"quirks": {"shift": true, "vblank": true}
+	}`
+	if err := os.WriteFile(path, []byte(conf), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	opts, err := ParseOptions(fs, []string{"--config", path, "--scale", "3", "--turbo-rate", "5", "--quirks", "vblank=0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if opts.Scale != 3 || opts.TurboRate != 5 {
+		t.Errorf("Scale, TurboRate = %d, %d, want the flag values 3, 5", opts.Scale, opts.TurboRate)
+	}
+	if opts.Volume != 0 || opts.Filter != FilterLinear || opts.Platform != "xo" || opts.Tickrate != 100 {
+		t.Errorf("config values not applied: %+v", opts)
+	}
+	if opts.Keymap["Up"] != chip8.Key5 {
+		t.Errorf("Keymap = %v, want Up bound to 5", opts.Keymap)
+	}
+
+	uc, err := opts.UserConf()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uc.Palette == nil || uc.Palette.Pixels[0].ToHex() != "#102030" || uc.Palette.Pixels[2] != DefaultPalette.Pixels[2] {
+		t.Errorf("palette = %v", uc.Palette)
+	}
+
+	var q chip8.Quirks
+	if err := ParseQuirks(uc.Quirks, &q); err != nil || !q.Shift || q.WaitVBlank {
+		t.Errorf("quirks %q: shift from the file and vblank from the flag expected", uc.Quirks)
+	}
+}
+
+func TestParseOptionsConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, conf := range map[string]string{
+		"syntax":   `{"scale": }`,
+		"platform": `{"platform": "megachip"}`,
+		"quirk":    `{"quirks": {"fast": true}}`,
+		"key":      `{"keymap": {"Up": "G"}}`,
+		"key name": `{"keymap": {"": "1"}}`,
+		"turbo":    `{"turbo": {"": "1"}}`,
+		"palette":  `{"palette": ["red"]}`,
+		"filter":   `{"filter": "blur"}`,
+	} {
+		path := filepath.Join(dir, name+".json")
+		if err := os.WriteFile(path, []byte(conf), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		fs := flag.NewFlagSet(name, flag.ContinueOnError)
+		if _, err := ParseOptions(fs, []string{"--config", path}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	// An explicitly given config file must exist.
+	fs := flag.NewFlagSet("missing", flag.ContinueOnError)
+	if _, err := ParseOptions(fs, []string{"--config", filepath.Join(dir, "none.json")}); err == nil {
+		t.Error("missing --config file should be an error")
+	}
+}
+
+func TestLoadROMAppliesUserConf(t *testing.T) {
+	pal := DefaultPalette
+	pal.Pixels[0] = Color{1, 2, 3, 255}
+
+	emu, _ := NewEmu()
+	emu.User = UserConf{Palette: &pal, Platform: chip8.PlatformXOChip, Tickrate: 77, Quirks: "jump=1"}
+	if _, err := emu.LoadROM([]byte{0x12, 0x00}, ".bin"); err != nil {
+		t.Fatal(err)
+	}
+
+	if emu.Palette != pal {
+		t.Error("user palette not applied")
+	}
+	if got := emu.VM.Tickrate(); got != 77 {
+		t.Errorf("Tickrate = %d, want 77", got)
+	}
+	want := chip8.ConfByPlatform[chip8.PlatformXOChip].Quirks
+	want.Jump = true
+	if emu.VM.CPU.Quirks != want {
+		t.Errorf("Quirks = %+v, want xo quirks with jump", emu.VM.CPU.Quirks)
+	}
+}
+
+func TestApplyDatabase(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "platforms.json"), []byte(`[{"id": "custom", "name": "Custom"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	emu, _ := NewEmu()
+	opts := Options{DBDir: dir}
+	if err := opts.Apply(emu); err != nil {
+		t.Fatal(err)
+	}
+	if emu.MetaDB.Platform("custom") == nil || emu.MetaDB.Platform("originalChip8") == nil {
+		t.Error("database directory not merged")
+	}
+
+	opts.DBReplace = true
+	if err := opts.Apply(emu); err == nil {
+		t.Error("replacing with an incomplete database should fail")
+	}
+}
+
+func TestApplyAudio(t *testing.T) {
+	s := NewAudioStream(DefaultSampleRate)
+	opts := Options{Volume: 30, Mute: true}
+	opts.ApplyAudio(s)
+
+	if v := s.Volume(); v != 0.3 {
+		t.Errorf("Volume() = %v, want 0.3", v)
+	}
+	if !s.Muted() {
+		t.Error("Muted() = false, want true")
+	}
+}
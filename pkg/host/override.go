@@ -0,0 +1,206 @@
+package host
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strings"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+)
+
+// ROMOverride holds user corrections for one ROM. It is stored as JSON in
+// Emu.Store and applied by LoadROM after the MetaDB data and the user
+// config, so it wins over both.
+type ROMOverride struct {
+	Quirks   map[string]bool `json:"quirks,omitempty"` // database quirk names, see QuirkNames
+	Tickrate int             `json:"tickrate,omitempty"`
+	Palette  []string        `json:"palette,omitempty"` // hex colors, background first
+	// Keymap binds host key names to CHIP-8 keys "0"-"F", as in Config,
+	// replacing the user's bindings for those names.
+	Keymap   map[string]string `json:"keymap,omitempty"`
+	Font     string            `json:"font,omitempty"`     // font style name
+	Rotation *int              `json:"rotation,omitempty"` // clockwise degrees: 0, 90, 180 or 270
+}
+
+// Empty reports whether o changes nothing.
+func (o *ROMOverride) Empty() bool {
+	return len(o.Quirks) == 0 && o.Tickrate == 0 && len(o.Palette) == 0 &&
+		len(o.Keymap) == 0 && o.Font == "" && o.Rotation == nil
+}
+
+// Validate checks the quirk names, colors, keys and rotation.
+func (o *ROMOverride) Validate() error {
+	var q chip8.Quirks
+	for name := range o.Quirks {
+		if QuirkField(&q, name) == nil {
+			return fmt.Errorf("unknown quirk %q", name)
+		}
+	}
+
+	p := DefaultPalette
+	if err := o.applyPalette(&p); err != nil {
+		return err
+	}
+
+	if _, err := o.Keys(); err != nil {
+		return err
+	}
+
+	if !chip8.ValidFont(chip8.FontStyle(o.Font)) {
+		return fmt.Errorf("unknown font style %q (use %s)", o.Font, fontStyleList())
+	}
+
+	if o.Rotation != nil {
+		switch *o.Rotation {
+		case 0, 90, 180, 270:
+		default:
+			return fmt.Errorf("invalid rotation %d (use 0, 90, 180 or 270)", *o.Rotation)
+		}
+	}
+
+	return nil
+}
+
+// Keys parses Keymap.
+func (o *ROMOverride) Keys() (map[string]chip8.Key, error) {
+	keys := make(map[string]chip8.Key, len(o.Keymap))
+	for name, key := range o.Keymap {
+		if name == "" {
+			return nil, errors.New("keymap: empty key name")
+		}
+		k, err := ParseKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("keymap %q: %w", name, err)
+		}
+		keys[name] = k
+	}
+	return keys, nil
+}
+
+func (o *ROMOverride) applyPalette(p *Palette) error {
+	if len(o.Palette) > len(p.Pixels) {
+		return fmt.Errorf("palette has more than %d colors", len(p.Pixels))
+	}
+	for i, hex := range o.Palette {
+		if err := p.SetColor(i, hex); err != nil {
+			return fmt.Errorf("palette: %w", err)
+		}
+	}
+	return nil
+}
+
+// OverrideKey is the Store key of the override for the loaded ROM.
+func (e *Emu) OverrideKey() string {
+	return "overrides/" + e.ROMHash + ".json"
+}
+
+// loadOverride reads the override of the loaded ROM into Override, which is
+// left empty without a Store or a saved override.
+func (e *Emu) loadOverride() error {
+	e.Override = ROMOverride{}
+	if e.Store == nil || !e.Loaded() {
+		return nil
+	}
+
+	data, err := e.Store.Load(e.OverrideKey())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var o ROMOverride
+	if err := json.Unmarshal(data, &o); err != nil {
+		return fmt.Errorf("%s: %w", e.OverrideKey(), err)
+	}
+	if err := o.Validate(); err != nil {
+		return fmt.Errorf("%s: %w", e.OverrideKey(), err)
+	}
+
+	e.Override = o
+	return nil
+}
+
+// applyOverride applies the quirks, tickrate, palette and font of Override.
+// LoadROM applies its rotation.
+func (e *Emu) applyOverride() {
+	o := &e.Override
+	if o.Empty() {
+		return
+	}
+	slog.Info("ROM override:", "key", e.OverrideKey())
+
+	for name, v := range o.Quirks {
+		*QuirkField(&e.VM.CPU.Quirks, name) = v
+		e.QuirkSources[name] = "override"
+	}
+	if o.Tickrate > 0 {
+		e.VM.SetTickrate(o.Tickrate)
+	}
+	// Validated on load.
+	_ = o.applyPalette(&e.Palette)
+
+	if o.Font != "" {
+		e.VM.Memory.SetFont(chip8.FontStyle(o.Font))
+	}
+}
+
+func fontStyleList() string {
+	styles := chip8.FontStyles()
+	names := make([]string, len(styles))
+	for i, s := range styles {
+		names[i] = string(s)
+	}
+	return strings.Join(names, ", ")
+}
+
+// SaveOverride stores o for the loaded ROM and makes it the current
+// Override. An empty override clears the saved one.
+func (e *Emu) SaveOverride(o ROMOverride) error {
+	if e.Store == nil {
+		return errors.New("no store configured")
+	}
+	if !e.Loaded() {
+		return errors.New("no ROM loaded")
+	}
+	if err := o.Validate(); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(&o, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := e.Store.Save(e.OverrideKey(), append(data, '\n')); err != nil {
+		return err
+	}
+
+	e.Override = o
+	return nil
+}
+
+// LiveOverride captures the current quirks, tickrate, palette and rotation,
+// for example after editing them in a settings panel, keeping the keymap and
+// font of the current Override.
+func (e *Emu) LiveOverride() ROMOverride {
+	o := e.Override
+	o.Tickrate = e.VM.Tickrate()
+	rotation := e.Rotation()
+	o.Rotation = &rotation
+
+	o.Quirks = make(map[string]bool, len(QuirkNames))
+	for _, name := range QuirkNames {
+		o.Quirks[name] = *QuirkField(&e.VM.CPU.Quirks, name)
+	}
+
+	o.Palette = make([]string, len(e.Palette.Pixels))
+	for i, c := range e.Palette.Pixels {
+		o.Palette[i] = c.ToHex()
+	}
+
+	return o
+}
@@ -0,0 +1,138 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+	"github.com/mxmgorin/ch8go/pkg/db"
+)
+
+func TestOverrideWinsOverMeta(t *testing.T) {
+	rom := []byte{0x12, 0x00}
+
+	emu, _ := NewEmu()
+	emu.Store = DirStore(t.TempDir())
+	emu.User.Tickrate = 50
+	if _, err := emu.LoadROM(rom, ".ch8"); err != nil {
+		t.Fatal(err)
+	}
+	if !emu.Override.Empty() {
+		t.Fatalf("Override = %+v, want empty", emu.Override)
+	}
+
+	o := ROMOverride{
+		Quirks:   map[string]bool{"shift": true},
+		Tickrate: 200,
+		Palette:  []string{"#112233"},
+		Keymap:   map[string]string{"Up": "5"},
+	}
+	if err := emu.SaveOverride(o); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := emu.LoadROM(rom, ".ch8"); err != nil {
+		t.Fatal(err)
+	}
+	if got := emu.VM.Tickrate(); got != 200 {
+		t.Errorf("Tickrate = %d, want the override over the user config", got)
+	}
+	if !emu.VM.CPU.Quirks.Shift {
+		t.Error("shift quirk not overridden")
+	}
+	if got := emu.Palette.Pixels[0].ToHex(); got != "#112233" {
+		t.Errorf("background = %s, want #112233", got)
+	}
+	if keys, err := emu.Override.Keys(); err != nil || keys["Up"] != chip8.Key5 {
+		t.Errorf("Keys = %v, %v", keys, err)
+	}
+
+	if err := emu.SaveOverride(ROMOverride{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := emu.LoadROM(rom, ".ch8"); err != nil {
+		t.Fatal(err)
+	}
+	if got := emu.VM.Tickrate(); got != 50 {
+		t.Errorf("Tickrate = %d after clearing, want the user config 50", got)
+	}
+}
+
+func TestLiveOverride(t *testing.T) {
+	emu, _ := NewEmu()
+	emu.Override.Font = "octo"
+	if err := emu.SetRotation(90); err != nil {
+		t.Fatal(err)
+	}
+	emu.VM.SetTickrate(42)
+	emu.VM.CPU.Quirks = chip8.Quirks{Wrap: true}
+
+	o := emu.LiveOverride()
+	if o.Tickrate != 42 || !o.Quirks["wrap"] || o.Quirks["shift"] || len(o.Quirks) != len(QuirkNames) {
+		t.Errorf("LiveOverride = %+v", o)
+	}
+	if o.Rotation == nil || *o.Rotation != 90 || o.Font != "octo" || len(o.Palette) != 16 {
+		t.Errorf("LiveOverride should capture rotation and palette and keep the font: %+v", o)
+	}
+}
+
+func TestOverrideZeroRotation(t *testing.T) {
+	emu, _ := NewEmu()
+	emu.Store = DirStore(t.TempDir())
+
+	// The database shows Sub-8 rotated by 270 degrees.
+	path := "../../testdata/roms/chip8archive/sc/sub8.ch8"
+	if _, err := emu.ReadROM(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := emu.SetRotation(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := emu.SaveOverride(emu.LiveOverride()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := emu.ReadROM(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := emu.Rotation(); got != 0 {
+		t.Errorf("Rotation = %d, want the saved 0 over the database", got)
+	}
+}
+
+func TestROMOverrideValidate(t *testing.T) {
+	for _, o := range []ROMOverride{
+		{Quirks: map[string]bool{"turbo": true}},
+		{Palette: []string{"nope"}},
+		{Keymap: map[string]string{"Up": "10"}},
+		{Keymap: map[string]string{"": "1"}},
+		{Rotation: intPtr(45)},
+		{Font: "comic"},
+	} {
+		if err := o.Validate(); err == nil {
+			t.Errorf("Validate(%+v) expected an error", o)
+		}
+	}
+}
+
+func TestROMConfQuirkyPlatform(t *testing.T) {
+	emu, _ := NewEmu()
+	meta := &db.ROMMeta{
+		QuirkyPlatforms: map[string]db.QuirkOverrides{"superchip": {"shift": false}},
+		FontStyle:       "octo",
+	}
+
+	conf := emu.ROMConf(meta, ".ch8")
+	want := emu.ROMConf(&db.ROMMeta{Platforms: []string{"superchip"}}, ".ch8").Quirks
+	want.Shift = false
+	if conf.Quirks != want {
+		t.Errorf("Quirks = %+v, want superchip without shift %+v", conf.Quirks, want)
+	}
+	if conf.Font != chip8.FontOcto {
+		t.Errorf("Font = %q, want octo", conf.Font)
+	}
+
+	meta.FontStyle = "akouz1"
+	if conf := emu.ROMConf(meta, ".ch8"); conf.Font != "" {
+		t.Errorf("unsupported font style should fall back to the default, got %q", conf.Font)
+	}
+}
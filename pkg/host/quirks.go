@@ -0,0 +1,127 @@
+package host
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+	"github.com/mxmgorin/ch8go/pkg/db"
+)
+
+// QuirkNames lists the quirks by their CHIP-8 database names, in the order
+// they are reported.
+var QuirkNames = db.QuirkNames
+
+// QuirkField returns a pointer to the chip8.Quirks field with the given
+// database name, or nil if the name is unknown.
+func QuirkField(q *chip8.Quirks, name string) *bool {
+	switch name {
+	case "shift":
+		return &q.Shift
+	case "memoryIncrementByX":
+		return &q.MemIncIByX
+	case "memoryLeaveIUnchanged":
+		return &q.MemLeaveI
+	case "wrap":
+		return &q.Wrap
+	case "jump":
+		return &q.Jump
+	case "vblank":
+		return &q.WaitVBlank
+	case "logic":
+		return &q.ResetFlag
+	case "scaleScroll":
+		return &q.ScaleScroll
+	}
+	return nil
+}
+
+// ParseQuirks applies a comma-separated list of overrides such as
+// "shift=1,wrap=false" to q. Names are matched case-insensitively.
+func ParseQuirks(spec string, q *chip8.Quirks) error {
+	_, err := applyQuirkSpec(spec, q)
+	return err
+}
+
+// applyQuirkSpec is ParseQuirks returning the database names of the quirks
+// it set.
+func applyQuirkSpec(spec string, q *chip8.Quirks) ([]string, error) {
+	var names []string
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return names, fmt.Errorf("invalid quirk %q (use name=value)", item)
+		}
+
+		canonical := canonicalQuirk(strings.TrimSpace(name))
+		field := QuirkField(q, canonical)
+		if field == nil {
+			return names, fmt.Errorf("unknown quirk %q", name)
+		}
+
+		v, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return names, fmt.Errorf("invalid value for quirk %q: %w", name, err)
+		}
+		*field = v
+		names = append(names, canonical)
+	}
+
+	return names, nil
+}
+
+// QuirkSources maps quirk names to the configuration layer that decided
+// them, such as "platform superchip" or "override". Emu.LoadROM fills it in
+// layer order: the extension or default platform, the user config, the
+// database platform, the ROM's quirkyPlatforms entry, Octo options and the
+// ROM override.
+type QuirkSources map[string]string
+
+func (s QuirkSources) setAll(source string) {
+	for _, name := range QuirkNames {
+		s[name] = source
+	}
+}
+
+func (s QuirkSources) set(names []string, source string) {
+	for _, name := range names {
+		s[name] = source
+	}
+}
+
+// Report formats q as "name=value (source)" pairs in QuirkNames order.
+func (s QuirkSources) Report(q chip8.Quirks) string {
+	parts := make([]string, len(QuirkNames))
+	for i, name := range QuirkNames {
+		src := s[name]
+		if src == "" {
+			src = "unknown"
+		}
+		parts[i] = fmt.Sprintf("%s=%t (%s)", name, *QuirkField(&q, name), src)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// QuirksString formats q as "name=value" pairs accepted by ParseQuirks.
+func QuirksString(q chip8.Quirks) string {
+	parts := make([]string, len(QuirkNames))
+	for i, name := range QuirkNames {
+		parts[i] = fmt.Sprintf("%s=%t", name, *QuirkField(&q, name))
+	}
+	return strings.Join(parts, ",")
+}
+
+func canonicalQuirk(name string) string {
+	for _, n := range QuirkNames {
+		if strings.EqualFold(n, name) {
+			return n
+		}
+	}
+	return name
+}
@@ -0,0 +1,123 @@
+package host
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+	"github.com/mxmgorin/ch8go/pkg/db"
+)
+
+func TestQuirkField(t *testing.T) {
+	var q chip8.Quirks
+	for _, name := range QuirkNames {
+		f := QuirkField(&q, name)
+		if f == nil {
+			t.Fatalf("QuirkField(%q) = nil", name)
+		}
+		*f = true
+	}
+
+	all := chip8.Quirks{
+		Shift: true, MemIncIByX: true, MemLeaveI: true, Wrap: true,
+		Jump: true, WaitVBlank: true, ResetFlag: true, ScaleScroll: true,
+	}
+	if q != all {
+		t.Errorf("setting every named quirk = %+v, want all true", q)
+	}
+	if QuirkField(&q, "nope") != nil {
+		t.Error("QuirkField(unknown) should return nil")
+	}
+}
+
+func TestParseQuirks(t *testing.T) {
+	q := chip8.QuirksChip8
+	if err := ParseQuirks("shift=1, VBLANK=false,logic=0,", &q); err != nil {
+		t.Fatalf("ParseQuirks error = %v", err)
+	}
+	if !q.Shift || q.WaitVBlank || q.ResetFlag {
+		t.Errorf("ParseQuirks result = %+v", q)
+	}
+
+	for _, bad := range []string{"shift", "nope=1", "wrap=maybe"} {
+		if err := ParseQuirks(bad, &q); err == nil {
+			t.Errorf("ParseQuirks(%q) expected an error", bad)
+		}
+	}
+}
+
+func TestQuirksStringRoundTrip(t *testing.T) {
+	var q chip8.Quirks
+	if err := ParseQuirks(QuirksString(chip8.QuirksSChip11), &q); err != nil {
+		t.Fatalf("ParseQuirks(QuirksString) error = %v", err)
+	}
+	if q != chip8.QuirksSChip11 {
+		t.Errorf("round-trip = %+v, want %+v", q, chip8.QuirksSChip11)
+	}
+}
+
+func TestQuirkSources(t *testing.T) {
+	emu, _ := NewEmu()
+	emu.Store = DirStore(t.TempDir())
+	emu.User.Quirks = "wrap=1"
+
+	// Listed with quirkyPlatforms {"xochip": {"memoryLeaveIUnchanged": true}}.
+	path := "../../testdata/roms/chip8archive/xo/superOctoTrackXO.ch8"
+	if _, err := emu.ReadROM(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := emu.SaveOverride(ROMOverride{Quirks: map[string]bool{"shift": true}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := emu.ReadROM(path); err != nil {
+		t.Fatal(err)
+	}
+
+	want := QuirkSources{
+		"shift":                 "override",
+		"memoryIncrementByX":    "platform xochip",
+		"memoryLeaveIUnchanged": "quirkyPlatforms xochip",
+		"wrap":                  "platform xochip", // over the user default
+		"jump":                  "platform xochip",
+		"vblank":                "platform xochip",
+		"logic":                 "platform xochip",
+		"scaleScroll":           "platform xochip",
+	}
+	for name, src := range want {
+		if got := emu.QuirkSources[name]; got != src {
+			t.Errorf("source of %s = %q, want %q", name, got, src)
+		}
+	}
+	if !emu.VM.CPU.Quirks.MemLeaveI || !emu.VM.CPU.Quirks.Wrap || !emu.VM.CPU.Quirks.Shift {
+		t.Errorf("Quirks = %+v", emu.VM.CPU.Quirks)
+	}
+
+	if _, err := emu.LoadROM([]byte{0x12, 0x00}, ".sc8"); err != nil {
+		t.Fatal(err)
+	}
+	if got := emu.QuirkSources["jump"]; got != "extension .sc8" {
+		t.Errorf("unknown ROM source = %q, want the extension", got)
+	}
+	if got := emu.QuirkSources["wrap"]; got != "user config" || !emu.VM.CPU.Quirks.Wrap {
+		t.Errorf("unknown ROM wrap source = %q, want the user default", got)
+	}
+	report := emu.QuirkSources.Report(emu.VM.CPU.Quirks)
+	if !strings.HasPrefix(report, "shift=true (extension .sc8), ") {
+		t.Errorf("Report = %q", report)
+	}
+}
+
+func TestUserConfDefaults(t *testing.T) {
+	emu, _ := NewEmu()
+	emu.User.Tickrate = 50
+	emu.User.Quirks = "shift=1"
+
+	if conf := emu.ROMConf(nil, ".ch8"); conf.Tickrate != 50 || !conf.Quirks.Shift {
+		t.Errorf("unknown ROM conf = %+v, want the user defaults", conf)
+	}
+
+	meta := &db.ROMMeta{Platforms: []string{"originalChip8"}, Tickrate: 200}
+	if conf := emu.ROMConf(meta, ".ch8"); conf.Tickrate != 200 || conf.Quirks.Shift {
+		t.Errorf("database ROM conf = %+v, want the database tickrate and quirks", conf)
+	}
+}
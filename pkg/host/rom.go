@@ -0,0 +1,283 @@
+package host
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+	"github.com/mxmgorin/ch8go/pkg/db"
+	"github.com/mxmgorin/ch8go/pkg/patch"
+)
+
+// ReadROM loads the ROM file at p. Besides raw ROMs it reads zip files,
+// where "pack.zip/game.ch8" names a member and "pack.zip" the only ROM in
+// it, and Octo cartridge GIFs. Octo options in a sidecar file next to the
+// file, such as "game.json" for "game.ch8", are applied with the ROM, and
+// patches next to the ROM, "game.ips" or "game.bps", are applied to it.
+func (e *Emu) ReadROM(p string) (int, error) {
+	name, member := splitZipPath(p)
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return 0, err
+	}
+
+	opts, err := ReadSidecar(name)
+	if err != nil {
+		return 0, err
+	}
+
+	if strings.ToLower(filepath.Ext(name)) == ".gif" {
+		return e.readCartridge(name, data, opts)
+	}
+	return e.loadFile(data, name, member, opts, true)
+}
+
+// SidecarPath returns the Octo options file of the ROM file at p.
+func SidecarPath(p string) string {
+	return strings.TrimSuffix(p, filepath.Ext(p)) + ".json"
+}
+
+// ReadSidecar reads the Octo options file of the ROM file at p, returning
+// nil if there is none.
+func ReadSidecar(p string) (*OctoOptions, error) {
+	sidecar := SidecarPath(p)
+	data, err := os.ReadFile(sidecar)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var o OctoOptions
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, fmt.Errorf("%s: %w", sidecar, err)
+	}
+	if err := o.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", sidecar, err)
+	}
+	slog.Info("Octo options:", "file", sidecar)
+	return &o, nil
+}
+
+// LoadFile loads the ROM in data read from a file called name: a raw ROM,
+// the member of a zip file (empty for its only ROM) or an Octo cartridge.
+func (e *Emu) LoadFile(data []byte, name, member string) (int, error) {
+	return e.loadFile(data, name, member, nil, false)
+}
+
+// loadFile is LoadFile with Octo options that replace those of a
+// cartridge, applying the patches next to the ROM when patches is set.
+func (e *Emu) loadFile(data []byte, name, member string, opts *OctoOptions, patches bool) (int, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".zip":
+		rom, member, err := ZipROM(data, member)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", name, err)
+		}
+		slog.Info("Zip:", "file", name, "member", member)
+		ext := strings.ToLower(path.Ext(member))
+		if patches {
+			return e.loadPatched(rom, zipMemberPath(name, member), ext, opts)
+		}
+		return e.LoadROMWithOptions(rom, ext, opts)
+
+	case ".gif":
+		c, err := ParseCartridge(bytes.NewReader(data))
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", name, err)
+		}
+		rom, err := c.ROM()
+		if errors.Is(err, ErrCartridgeSource) {
+			return 0, fmt.Errorf("%s: %w; compile it in Octo and load the ROM instead", name, err)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", name, err)
+		}
+		if opts == nil {
+			opts = &c.Options
+		}
+		return e.LoadROMWithOptions(rom, ".gif", opts)
+
+	default:
+		if patches {
+			return e.loadPatched(data, name, filepath.Ext(name), opts)
+		}
+		return e.LoadROMWithOptions(data, filepath.Ext(name), opts)
+	}
+}
+
+// loadPatched loads rom with the patches next to the ROM file at p applied,
+// keeping the hash of rom as the SourceHash.
+func (e *Emu) loadPatched(rom []byte, p, ext string, opts *OctoOptions) (int, error) {
+	source := db.SHA1Of(rom)
+	rom, err := ApplyPatches(rom, p)
+	if err != nil {
+		return 0, err
+	}
+	return e.loadROM(rom, ext, opts, source)
+}
+
+// ApplyPatches applies the patches next to the ROM file at p, "game.ips"
+// and then "game.bps" for "game.ch8", returning rom itself if there are
+// none.
+func ApplyPatches(rom []byte, p string) ([]byte, error) {
+	base := strings.TrimSuffix(p, filepath.Ext(p))
+	for _, ext := range patch.Exts {
+		file := base + ext
+		data, err := os.ReadFile(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if rom, err = patch.Apply(rom, data); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		slog.Info("Patch applied:", "file", file)
+	}
+	return rom, nil
+}
+
+// zipMemberPath returns where files belonging to a zip member are looked
+// for: next to the zip file, under the member's base name.
+func zipMemberPath(name, member string) string {
+	return filepath.Join(filepath.Dir(name), path.Base(member))
+}
+
+// readCartridge loads the cartridge at name with its options, or opts if
+// set, taking the program from a compiled ROM of the same name next to it
+// when there is one, since cartridges hold Octo source.
+func (e *Emu) readCartridge(name string, data []byte, opts *OctoOptions) (int, error) {
+	c, err := ParseCartridge(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	if opts == nil {
+		opts = &c.Options
+	}
+
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	for _, ext := range romExts() {
+		rom, err := os.ReadFile(base + ext)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		slog.Info("Cartridge:", "file", name, "rom", base+ext)
+		return e.loadPatched(rom, base+ext, ext, opts)
+	}
+
+	rom, err := c.ROM()
+	if errors.Is(err, ErrCartridgeSource) {
+		return 0, fmt.Errorf("%s: %w; compile it in Octo and save the ROM next to it as %s", name, err, filepath.Base(base)+".ch8")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return e.LoadROMWithOptions(rom, ".gif", opts)
+}
+
+// ZipROM returns the ROM in the zip file data and its member name. An
+// empty name selects the only file with a ROM extension, or else the only
+// file; otherwise name matches a member's path or base name, ignoring case.
+func ZipROM(data []byte, name string) ([]byte, string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, "", err
+	}
+
+	var files, roms []*zip.File
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		files = append(files, f)
+		if _, ok := chip8.PlatformByExt[strings.ToLower(path.Ext(f.Name))]; ok {
+			roms = append(roms, f)
+		}
+	}
+
+	var found []*zip.File
+	switch {
+	case name != "":
+		for _, f := range files {
+			if strings.EqualFold(f.Name, name) || strings.EqualFold(path.Base(f.Name), name) {
+				found = append(found, f)
+			}
+		}
+		if len(found) == 0 {
+			return nil, "", fmt.Errorf("%s not found; members: %s", name, memberNames(files))
+		}
+	case len(roms) > 0:
+		found = roms
+	default:
+		found = files
+	}
+
+	if len(found) == 0 {
+		return nil, "", errors.New("empty zip file")
+	}
+	if len(found) > 1 {
+		return nil, "", fmt.Errorf("several ROMs, choose one of: %s", memberNames(found))
+	}
+
+	f := found[0]
+	if f.UncompressedSize64 > maxROMSize {
+		return nil, "", fmt.Errorf("%s: too large for memory", f.Name)
+	}
+	rom, err := readZipFile(f)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", f.Name, err)
+	}
+	return rom, f.Name, nil
+}
+
+func memberNames(files []*zip.File) string {
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// splitZipPath splits "pack.zip/game.ch8" into the zip file and member
+// name. Paths that are files themselves are returned whole.
+func splitZipPath(p string) (name, member string) {
+	if _, err := os.Stat(p); err == nil {
+		return p, ""
+	}
+
+	i := strings.Index(strings.ToLower(p), ".zip"+string(filepath.Separator))
+	if i < 0 && filepath.Separator != '/' {
+		i = strings.Index(strings.ToLower(p), ".zip/")
+	}
+	if i < 0 {
+		return p, ""
+	}
+	return p[:i+4], filepath.ToSlash(p[i+5:])
+}
+
+// romExts returns the ROM extensions in a stable order.
+func romExts() []string {
+	exts := make([]string, 0, len(chip8.PlatformByExt))
+	for ext := range chip8.PlatformByExt {
+		exts = append(exts, ext)
+	}
+	slices.Sort(exts)
+	return exts
+}
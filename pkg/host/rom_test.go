@@ -0,0 +1,210 @@
+package host
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+)
+
+func TestZipROM(t *testing.T) {
+	dir := t.TempDir()
+	single := filepath.Join(dir, "single.zip")
+	writeZip(t, single, map[string][]byte{"game.ch8": {0x12, 0x00}, "readme.txt": []byte("x")})
+	pack := filepath.Join(dir, "pack.zip")
+	writeZip(t, pack, map[string][]byte{"a/one.ch8": {0x01}, "two.sc8": {0x02}})
+
+	tests := []struct {
+		file, name string
+		want       []byte
+		wantMember string
+		wantErr    string
+	}{
+		{single, "", []byte{0x12, 0x00}, "game.ch8", ""},
+		{pack, "a/one.ch8", []byte{0x01}, "a/one.ch8", ""},
+		{pack, "ONE.CH8", []byte{0x01}, "a/one.ch8", ""},
+		{pack, "", nil, "", "several ROMs"},
+		{pack, "three.ch8", nil, "", "not found"},
+	}
+	for _, tt := range tests {
+		data, err := os.ReadFile(tt.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rom, member, err := ZipROM(data, tt.name)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ZipROM(%s, %q) error = %v, want %q", tt.file, tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ZipROM(%s, %q): %v", tt.file, tt.name, err)
+			continue
+		}
+		if !bytes.Equal(rom, tt.want) || member != tt.wantMember {
+			t.Errorf("ZipROM(%s, %q) = %x, %q, want %x, %q", tt.file, tt.name, rom, member, tt.want, tt.wantMember)
+		}
+	}
+}
+
+func TestReadROMZipPath(t *testing.T) {
+	dir := t.TempDir()
+	pack := filepath.Join(dir, "pack.zip")
+	writeZip(t, pack, map[string][]byte{"one.ch8": {0x12, 0x00}, "two.xo8": {0x12, 0x02}})
+
+	emu, _ := NewEmu()
+	n, err := emu.ReadROM(filepath.Join(pack, "two.xo8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || emu.VM.Tickrate() != chip8.ConfByPlatform[chip8.PlatformXOChip].Tickrate {
+		t.Errorf("ReadROM loaded %d bytes at tickrate %d, want the XO-CHIP member", n, emu.VM.Tickrate())
+	}
+}
+
+func TestCartridge(t *testing.T) {
+	f, err := os.Open("../../testdata/roms/chip8archive/xo/D8GN.gif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	c, err := ParseCartridge(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Options.Tickrate != 100 || c.Options.FillColor != "#332c50" || c.Options.FontStyle != "octo" || c.Options.MaxSize != 65024 {
+		t.Errorf("Options = %+v", c.Options)
+	}
+	if _, err := c.ROM(); !errors.Is(err, ErrCartridgeSource) {
+		t.Errorf("ROM() of Octo source error = %v, want the assembler error", err)
+	}
+
+	// Without the compiled ROM next to it, as in the web build.
+	data, err := os.ReadFile("../../testdata/roms/chip8archive/xo/D8GN.gif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	emu, _ := NewEmu()
+	if _, err := emu.LoadFile(data, "D8GN.gif", ""); !errors.Is(err, ErrCartridgeSource) || !strings.Contains(err.Error(), "compile it in Octo") {
+		t.Errorf("LoadFile of a source cartridge error = %v", err)
+	}
+
+	if _, err := emu.ReadROM("../../testdata/roms/chip8archive/xo/D8GN.gif"); err != nil {
+		t.Fatal(err)
+	}
+	ch8, err := os.ReadFile("../../testdata/roms/chip8archive/xo/D8GN.ch8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if emu.VM.Memory.Read(chip8.ProgramStart) != ch8[0] {
+		t.Error("ReadROM did not load the compiled ROM next to the cartridge")
+	}
+	if emu.VM.Tickrate() != 100 || emu.QuirkSources["shift"] != "octo options" {
+		t.Errorf("tickrate %d, sources %v, want the cartridge options", emu.VM.Tickrate(), emu.QuirkSources)
+	}
+	if want, _ := ParseHexColor("#e2f3e4"); emu.Palette.Pixels[0] != want {
+		t.Errorf("background = %v, want %v", emu.Palette.Pixels[0], want)
+	}
+}
+
+func TestCartridgeByteListing(t *testing.T) {
+	data := encodeCartridge(t, Cartridge{
+		Program: ": main\n0x12 0x00 # loop\n255 -1\n",
+		Options: OctoOptions{Tickrate: 7, ClipQuirks: boolPtr(true), ScreenRotation: intPtr(90)},
+	})
+
+	emu, _ := NewEmu()
+	n, err := emu.LoadFile(data, "game.gif", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0x12, 0x00, 0xFF, 0xFF}
+	got := make([]byte, n)
+	for i := range got {
+		got[i] = emu.VM.Memory.Read(uint16(chip8.ProgramStart + i))
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("loaded %x, want %x", got, want)
+	}
+	if emu.VM.Tickrate() != 7 || emu.VM.CPU.Quirks.Wrap || emu.Rotation() != 90 {
+		t.Errorf("tickrate %d, wrap %v, rotation %d, want the cartridge options", emu.VM.Tickrate(), emu.VM.CPU.Quirks.Wrap, emu.Rotation())
+	}
+}
+
+// encodeCartridge writes c as an Octo cartridge GIF.
+func encodeCartridge(t *testing.T, c Cartridge) []byte {
+	t.Helper()
+	payload, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := len(payload)
+	payload = append([]byte{byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}, payload...)
+
+	pal := make(color.Palette, 16)
+	for i := range pal {
+		pal[i] = color.Gray{Y: uint8(i * 16)}
+	}
+	img := image.NewPaletted(image.Rect(0, 0, 160, 128), pal)
+	for i, b := range payload {
+		img.Pix[2*i], img.Pix[2*i+1] = b>>4, b&0x0F
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, &gif.GIF{Image: []*image.Paletted{img}, Delay: []int{0}}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadROMPatches(t *testing.T) {
+	dir := t.TempDir()
+	rom := filepath.Join(dir, "game.ch8")
+	writeFile(t, rom, []byte{0x12, 0x00, 0x00})
+	writeFile(t, filepath.Join(dir, "game.ips"), []byte("PATCH\x00\x00\x01\x00\x02\x02\xAAEOF"))
+	writeZip(t, filepath.Join(dir, "pack.zip"), map[string][]byte{"games/game.ch8": {0x12, 0x00, 0x00}})
+
+	for _, p := range []string{rom, filepath.Join(dir, "pack.zip")} {
+		emu, _ := NewEmu()
+		if _, err := emu.ReadROM(p); err != nil {
+			t.Fatal(err)
+		}
+		if got := emu.VM.Memory.ReadU16(chip8.ProgramStart + 1); got != 0x02AA {
+			t.Errorf("%s: patched bytes = %04x, want 02aa", p, got)
+		}
+	}
+
+	// A patched ROM is still the database's Super Octo Track.
+	track, err := os.ReadFile("../../testdata/roms/chip8archive/xo/superOctoTrackXO.ch8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "track.ch8"), track)
+	writeFile(t, filepath.Join(dir, "track.ips"), []byte("PATCH\x00\x00\x01\x00\x01\xAAEOF"))
+	emu, _ := NewEmu()
+	if _, err := emu.ReadROM(filepath.Join(dir, "track.ch8")); err != nil {
+		t.Fatal(err)
+	}
+	if emu.ROMHash == emu.SourceHash || emu.ROMMeta() == nil {
+		t.Fatalf("ROMHash %s, SourceHash %s: want the patched ROM found by its source", emu.ROMHash, emu.SourceHash)
+	}
+	if got := emu.QuirkSources["memoryLeaveIUnchanged"]; got != "quirkyPlatforms xochip" {
+		t.Errorf("memoryLeaveIUnchanged source = %q, want the database's", got)
+	}
+
+	writeFile(t, filepath.Join(dir, "game.bps"), []byte("BPS1 not for this ROM"))
+	emu, _ = NewEmu()
+	if _, err := emu.ReadROM(rom); err == nil || !strings.Contains(err.Error(), "game.bps") {
+		t.Errorf("ReadROM with a bad patch: error = %v", err)
+	}
+}
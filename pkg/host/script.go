@@ -0,0 +1,388 @@
+package host
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+)
+
+// DefaultWaitFrames bounds "wait until" steps that do not give their own
+// "within" limit (one minute of emulated time).
+const DefaultWaitFrames = 3600
+
+// InputScript is a scripted scenario executed by Emu.RunScript.
+//
+// Steps run in order against a frame cursor starting at 0. The format has
+// one step per line; blank lines and '#' comments are ignored:
+//
+//	at frame 120 press 5 for 3 frames   # wait until frame 120, hold 5 for 3 frames
+//	press a                             # press at the current frame
+//	release a
+//	wait 60 frames
+//	wait until pixel (10,4) is lit      # display pixel in 128x64 space
+//	wait until pixel (10,4) is unlit within 300 frames
+//	screenshot as step1
+//
+// Keys are hex digits 0-F. Any step may start with "at frame <n>", which
+// first runs the emulator up to frame n.
+type InputScript struct {
+	Steps []ScriptStep
+}
+
+type ScriptOp int
+
+const (
+	ScriptPress ScriptOp = iota
+	ScriptRelease
+	ScriptWait
+	ScriptWaitPixel
+	ScriptScreenshot
+)
+
+// ScriptStep is one parsed line of an InputScript.
+type ScriptStep struct {
+	Line   int
+	Op     ScriptOp
+	At     int // absolute frame to reach first, or -1
+	Key    chip8.Key
+	Hold   int // ScriptPress: release after this many frames, 0 holds
+	Frames int // ScriptWait: frames to run; ScriptWaitPixel: timeout
+	X, Y   int
+	Lit    bool
+	Name   string
+}
+
+// Screenshot is a frame captured by a "screenshot as" step.
+type Screenshot struct {
+	Name  string
+	Frame int
+	PNG   []byte
+}
+
+// ScriptResult reports the outcome of Emu.RunScript.
+type ScriptResult struct {
+	Frames      int
+	Screenshots []Screenshot
+}
+
+func ParseInputScript(r io.Reader) (*InputScript, error) {
+	s := &InputScript{}
+	scanner := bufio.NewScanner(r)
+
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		tokens := strings.FieldsFunc(text, func(r rune) bool {
+			return r == ' ' || r == '\t' || r == '(' || r == ')' || r == ','
+		})
+		if len(tokens) == 0 {
+			continue
+		}
+
+		step, err := parseScriptStep(tokens)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		step.Line = line
+		s.Steps = append(s.Steps, step)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func LoadInputScript(path string) (*InputScript, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseInputScript(f)
+}
+
+// scriptTokens is a cursor over the tokens of one script line.
+type scriptTokens []string
+
+func (t *scriptTokens) peek(word string) bool {
+	return len(*t) > 0 && (*t)[0] == word
+}
+
+func (t *scriptTokens) next() (string, bool) {
+	if len(*t) == 0 {
+		return "", false
+	}
+	tok := (*t)[0]
+	*t = (*t)[1:]
+	return tok, true
+}
+
+func (t *scriptTokens) expect(words ...string) error {
+	for _, w := range words {
+		tok, ok := t.next()
+		if !ok {
+			return fmt.Errorf("expected %q at end of line", w)
+		}
+		if tok != w && !(w == "frames" && tok == "frame") {
+			return fmt.Errorf("expected %q, got %q", w, tok)
+		}
+	}
+	return nil
+}
+
+func (t *scriptTokens) number() (int, error) {
+	tok, ok := t.next()
+	if !ok {
+		return 0, fmt.Errorf("expected a number at end of line")
+	}
+	n, err := strconv.Atoi(tok)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid number %q", tok)
+	}
+	return n, nil
+}
+
+func (t *scriptTokens) key() (chip8.Key, error) {
+	tok, ok := t.next()
+	if !ok {
+		return 0, fmt.Errorf("expected a key at end of line")
+	}
+	return ParseKey(tok)
+}
+
+func parseScriptStep(tokens scriptTokens) (step ScriptStep, err error) {
+	step.At = -1
+
+	if tokens.peek("at") {
+		if err := tokens.expect("at", "frame"); err != nil {
+			return step, err
+		}
+		if step.At, err = tokens.number(); err != nil {
+			return step, err
+		}
+	}
+
+	verb, _ := tokens.next()
+	switch verb {
+	case "press":
+		step.Op = ScriptPress
+		if step.Key, err = tokens.key(); err != nil {
+			return step, err
+		}
+		if tokens.peek("for") {
+			tokens.next()
+			if step.Hold, err = tokens.number(); err != nil {
+				return step, err
+			}
+			if err := tokens.expect("frames"); err != nil {
+				return step, err
+			}
+		}
+
+	case "release":
+		step.Op = ScriptRelease
+		if step.Key, err = tokens.key(); err != nil {
+			return step, err
+		}
+
+	case "wait":
+		if !tokens.peek("until") {
+			step.Op = ScriptWait
+			if step.Frames, err = tokens.number(); err != nil {
+				return step, err
+			}
+			if err := tokens.expect("frames"); err != nil {
+				return step, err
+			}
+			break
+		}
+
+		step.Op = ScriptWaitPixel
+		step.Frames = DefaultWaitFrames
+		if err := tokens.expect("until", "pixel"); err != nil {
+			return step, err
+		}
+		if step.X, err = tokens.number(); err != nil {
+			return step, err
+		}
+		if step.Y, err = tokens.number(); err != nil {
+			return step, err
+		}
+		if err := tokens.expect("is"); err != nil {
+			return step, err
+		}
+		switch state, _ := tokens.next(); state {
+		case "lit":
+			step.Lit = true
+		case "unlit":
+			step.Lit = false
+		default:
+			return step, fmt.Errorf("expected \"lit\" or \"unlit\", got %q", state)
+		}
+		if tokens.peek("within") {
+			tokens.next()
+			if step.Frames, err = tokens.number(); err != nil {
+				return step, err
+			}
+			if err := tokens.expect("frames"); err != nil {
+				return step, err
+			}
+		}
+
+	case "screenshot":
+		step.Op = ScriptScreenshot
+		if err := tokens.expect("as"); err != nil {
+			return step, err
+		}
+		name, ok := tokens.next()
+		if !ok {
+			return step, fmt.Errorf("expected a screenshot name")
+		}
+		step.Name = name
+
+	case "":
+		return step, fmt.Errorf("missing action after \"at frame %d\"", step.At)
+
+	default:
+		return step, fmt.Errorf("unknown action %q", verb)
+	}
+
+	if len(tokens) > 0 {
+		return step, fmt.Errorf("unexpected %q", strings.Join(tokens, " "))
+	}
+
+	return step, nil
+}
+
+// ParseKey parses a CHIP-8 key given as a hex digit 0-F.
+func ParseKey(s string) (chip8.Key, error) {
+	v, err := strconv.ParseUint(s, 16, 8)
+	if err != nil || v > 0xF {
+		return 0, fmt.Errorf("invalid key %q (use 0-F)", s)
+	}
+	return chip8.Key(v), nil
+}
+
+type pendingRelease struct {
+	frame int
+	key   chip8.Key
+}
+
+// scriptRun tracks the frame cursor and timed releases while a script runs.
+type scriptRun struct {
+	emu      *Emu
+	frame    int
+	releases []pendingRelease
+}
+
+// advance releases keys that are due and runs one frame.
+func (r *scriptRun) advance() {
+	kept := r.releases[:0]
+	for _, rel := range r.releases {
+		if rel.frame <= r.frame {
+			r.emu.VM.Keypad.Release(rel.key)
+		} else {
+			kept = append(kept, rel)
+		}
+	}
+	r.releases = kept
+
+	r.emu.runFrame(FrameDelta)
+	r.frame++
+}
+
+func (r *scriptRun) runUntil(frame int) {
+	for r.frame < frame {
+		r.advance()
+	}
+}
+
+func (r *scriptRun) pixelLit(x, y int) (bool, error) {
+	size := r.emu.VM.Display.Size()
+	if x >= size.Width || y >= size.Height {
+		return false, fmt.Errorf("pixel (%d,%d) is outside the %dx%d display", x, y, size.Width, size.Height)
+	}
+
+	i := y*size.Width + x
+	for _, plane := range r.emu.VM.Display.Planes {
+		if plane[i] != 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *scriptRun) step(st ScriptStep, res *ScriptResult) error {
+	if st.At >= 0 {
+		if st.At < r.frame {
+			return fmt.Errorf("frame %d is in the past (current frame %d)", st.At, r.frame)
+		}
+		r.runUntil(st.At)
+	}
+
+	switch st.Op {
+	case ScriptPress:
+		r.emu.VM.Keypad.Press(st.Key)
+		if st.Hold > 0 {
+			r.releases = append(r.releases, pendingRelease{frame: r.frame + st.Hold, key: st.Key})
+		}
+
+	case ScriptRelease:
+		r.emu.VM.Keypad.Release(st.Key)
+
+	case ScriptWait:
+		r.runUntil(r.frame + st.Frames)
+
+	case ScriptWaitPixel:
+		for waited := 0; ; waited++ {
+			lit, err := r.pixelLit(st.X, st.Y)
+			if err != nil {
+				return err
+			}
+			if lit == st.Lit {
+				break
+			}
+			if waited >= st.Frames {
+				return fmt.Errorf("pixel (%d,%d) did not change within %d frames", st.X, st.Y, st.Frames)
+			}
+			r.advance()
+		}
+
+	case ScriptScreenshot:
+		data, err := r.emu.FrameBuffer.PNG()
+		if err != nil {
+			return err
+		}
+		res.Screenshots = append(res.Screenshots, Screenshot{Name: st.Name, Frame: r.frame, PNG: data})
+	}
+
+	return nil
+}
+
+// RunScript executes s and then keeps running until at least frames frames
+// have elapsed. A nil script runs frames frames without input.
+func (e *Emu) RunScript(s *InputScript, frames int) (*ScriptResult, error) {
+	run := scriptRun{emu: e}
+	res := &ScriptResult{}
+
+	if s != nil {
+		for _, st := range s.Steps {
+			if err := run.step(st, res); err != nil {
+				res.Frames = run.frame
+				return res, fmt.Errorf("line %d: %w", st.Line, err)
+			}
+		}
+	}
+
+	run.runUntil(frames)
+	res.Frames = run.frame
+
+	return res, nil
+}
@@ -0,0 +1,128 @@
+package host
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+)
+
+func TestParseInputScript(t *testing.T) {
+	src := `
+# hold 5, then wait for the screen
+at frame 8 press 5 for 2 frames # inline comment
+press a
+release a
+wait 1 frame
+wait until pixel (10, 4) is lit within 30 frames
+wait until pixel (0,0) is unlit
+screenshot as step1
+`
+	s, err := ParseInputScript(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ParseInputScript error = %v", err)
+	}
+
+	want := []ScriptStep{
+		{Line: 3, Op: ScriptPress, At: 8, Key: chip8.Key5, Hold: 2},
+		{Line: 4, Op: ScriptPress, At: -1, Key: chip8.KeyA},
+		{Line: 5, Op: ScriptRelease, At: -1, Key: chip8.KeyA},
+		{Line: 6, Op: ScriptWait, At: -1, Frames: 1},
+		{Line: 7, Op: ScriptWaitPixel, At: -1, X: 10, Y: 4, Lit: true, Frames: 30},
+		{Line: 8, Op: ScriptWaitPixel, At: -1, Frames: DefaultWaitFrames},
+		{Line: 9, Op: ScriptScreenshot, At: -1, Name: "step1"},
+	}
+	if len(s.Steps) != len(want) {
+		t.Fatalf("steps = %+v, want %+v", s.Steps, want)
+	}
+	for i, w := range want {
+		if s.Steps[i] != w {
+			t.Errorf("Steps[%d] = %+v, want %+v", i, s.Steps[i], w)
+		}
+	}
+}
+
+func TestParseInputScriptErrors(t *testing.T) {
+	for _, src := range []string{
+		"press",
+		"at frame x press 5",
+		"at frame 1 hold 5",
+		"at frame 1 press G",
+		"at frame 1",
+		"press 5 for 3",
+		"wait until pixel (1) is lit",
+		"wait until pixel (1,2) is on",
+		"screenshot step1",
+		"release 5 now",
+	} {
+		if _, err := ParseInputScript(strings.NewReader(src)); err == nil {
+			t.Errorf("ParseInputScript(%q) expected an error", src)
+		} else if !strings.Contains(err.Error(), "line 1") {
+			t.Errorf("error %q should name the line", err)
+		}
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	emu, _ := NewEmu()
+	// LD I, 020C ; LD V0, 05 ; SKP V0 ; JP 0204 ; DRW V1, V1, 1 ; JP 020A ; sprite 0x60
+	rom := []byte{0xA2, 0x0C, 0x60, 0x05, 0xE0, 0x9E, 0x12, 0x04, 0xD1, 0x11, 0x12, 0x0A, 0x60}
+	if _, err := emu.LoadROM(rom, ".ch8"); err != nil {
+		t.Fatal(err)
+	}
+
+	src := `
+at frame 3 press 5 for 2 frames
+screenshot as pressed
+wait until pixel (2,0) is lit within 5 frames
+at frame 5 screenshot as released
+`
+	s, err := ParseInputScript(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := emu.RunScript(s, 10)
+	if err != nil {
+		t.Fatalf("RunScript error = %v", err)
+	}
+	if res.Frames != 10 {
+		t.Errorf("Frames = %d, want 10", res.Frames)
+	}
+	if emu.VM.Keypad.IsPressed(chip8.Key5) {
+		t.Error("key should be released after its hold time")
+	}
+
+	if len(res.Screenshots) != 2 {
+		t.Fatalf("screenshots = %d, want 2", len(res.Screenshots))
+	}
+	for i, want := range []Screenshot{{Name: "pressed", Frame: 3}, {Name: "released", Frame: 5}} {
+		got := res.Screenshots[i]
+		if got.Name != want.Name || got.Frame != want.Frame || len(got.PNG) == 0 {
+			t.Errorf("Screenshots[%d] = %s at %d, want %s at %d", i, got.Name, got.Frame, want.Name, want.Frame)
+		}
+	}
+}
+
+func TestRunScriptErrors(t *testing.T) {
+	for _, src := range []string{
+		"wait until pixel (0,0) is lit within 3 frames",
+		"wait until pixel (200,0) is lit",
+		"at frame 5 press 1\nat frame 4 press 2",
+	} {
+		emu, _ := NewEmu()
+		// JP 0200
+		if _, err := emu.LoadROM([]byte{0x12, 0x00}, ".ch8"); err != nil {
+			t.Fatal(err)
+		}
+
+		s, err := ParseInputScript(strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := emu.RunScript(s, 0); err == nil {
+			t.Errorf("RunScript(%q) expected an error", src)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package host
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Store persists small per-user files such as cheat lists. Keys are
+// slash-separated relative paths like "cheats/<hash>.cht". Load returns an
+// error satisfying errors.Is(err, fs.ErrNotExist) for missing keys.
+type Store interface {
+	Load(key string) ([]byte, error)
+	Save(key string, data []byte) error
+}
+
+// DirStore is a Store backed by files below a directory.
+type DirStore string
+
+// UserStore returns a DirStore in the "ch8go" folder of the user
+// configuration directory.
+func UserStore() (DirStore, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return DirStore(filepath.Join(dir, "ch8go")), nil
+}
+
+func (d DirStore) Path(key string) string {
+	return filepath.Join(string(d), filepath.FromSlash(key))
+}
+
+func (d DirStore) Load(key string) ([]byte, error) {
+	return os.ReadFile(d.Path(key))
+}
+
+func (d DirStore) Save(key string, data []byte) error {
+	path := d.Path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,162 @@
+package testkit_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+	"github.com/mxmgorin/ch8go/pkg/host"
+	"github.com/mxmgorin/ch8go/pkg/host/testkit"
+)
+
+const (
+	keyFrames = 120
+	runFrames = 500_00
+	romDir    = "../../../testdata/roms/test"
+)
+
+var (
+	golden   = testkit.Golden{Dir: "../../../testdata/golden"}
+	romPaths = []string{
+		"corax_test_opcode.ch8",
+		"timendus/1-chip8-logo.ch8",
+		"timendus/2-ibm-logo.ch8",
+		"timendus/3-corax+.ch8",
+		"timendus/4-flags.ch8",
+		"octo/bigfont.ch8",
+		"octo/testbranch.ch8",
+		"octo/testcollide.ch8",
+		"octo/testcompare.ch8",
+		"octo/testquirks.ch8",
+		"octo/testunpack.ch8",
+	}
+)
+
+func TestROMs(t *testing.T) {
+	for _, path := range romPaths {
+		t.Run(path, func(t *testing.T) {
+			golden.AssertCase(t, testkit.Case{ROM: filepath.Join(romDir, path), Frames: runFrames})
+		})
+	}
+}
+
+func TestQuirks(t *testing.T) {
+	cases := []struct {
+		name   string
+		quirks chip8.Quirks
+		keys   []chip8.Key
+	}{
+		{"5-quirks_chip8", chip8.QuirksChip8, []chip8.Key{0x1}},
+		{"5-quirks_schip-modern", chip8.QuirksSChipModern, []chip8.Key{0x2, 0x1}},
+		{"5-quirks_schip-legacy", chip8.QuirksSChip11, []chip8.Key{0x2, 0x2}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			golden.AssertCase(t, testkit.Case{
+				Name:   c.name,
+				ROM:    filepath.Join(romDir, "timendus/5-quirks.ch8"),
+				Quirks: &c.quirks,
+				Script: testkit.Taps(keyFrames, c.keys...),
+				Frames: runFrames,
+			})
+		})
+	}
+}
+
+func TestScroll(t *testing.T) {
+	cases := []struct {
+		name   string
+		quirks chip8.Quirks
+		keys   []chip8.Key
+	}{
+		{"8-scrolling_schip-lowres-legacy", chip8.QuirksSChip11, []chip8.Key{0x1, 0x1, 0x2}},
+		{"8-scrolling_schip-lowres-modern", chip8.QuirksSChipModern, []chip8.Key{0x1, 0x1, 0x1}},
+		{"8-scrolling_schip-hires-modern", chip8.QuirksSChipModern, []chip8.Key{0x1, 0x2}},
+		{"8-scrolling_xo-chip-lowres", chip8.QuirksXOChip, []chip8.Key{0x2, 0x1}},
+		{"8-scrolling_xo-chip-hires", chip8.QuirksXOChip, []chip8.Key{0x2, 0x2}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			golden.AssertCase(t, testkit.Case{
+				Name:   c.name,
+				ROM:    filepath.Join(romDir, "timendus/8-scrolling.ch8"),
+				Quirks: &c.quirks,
+				Script: testkit.Taps(keyFrames, c.keys...),
+				Frames: runFrames,
+			})
+		})
+	}
+}
+
+func TestKeypad(t *testing.T) {
+	cases := []struct {
+		name    string
+		keys    []chip8.Key
+		holdAll bool // keep every key pressed after the menu
+	}{
+		{"6-keypad_key-down", []chip8.Key{0x1}, true},
+		{"6-keypad_key-up", []chip8.Key{0x2}, true},
+		{"6-keypad_get-key", []chip8.Key{0x3, 0x3}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			script := testkit.Taps(keyFrames, c.keys...)
+			if c.holdAll {
+				for key := range chip8.KeyCount {
+					script.Steps = append(script.Steps, host.ScriptStep{Op: host.ScriptPress, At: -1, Key: key})
+				}
+			}
+
+			golden.AssertCase(t, testkit.Case{
+				Name:   c.name,
+				ROM:    filepath.Join(romDir, "timendus/6-keypad.ch8"),
+				Quirks: &chip8.QuirksSChipModern,
+				Script: script,
+				Frames: runFrames,
+			})
+		})
+	}
+}
+
+// TestScenarios runs the input scripts in testdata/scripts and checks every
+// screenshot they take against the golden image of the same name.
+func TestScenarios(t *testing.T) {
+	scenarios := []struct {
+		script string
+		rom    string
+		quirks chip8.Quirks
+	}{
+		{"5-quirks_chip8.txt", "timendus/5-quirks.ch8", chip8.QuirksChip8},
+		{"6-keypad_get-key.txt", "timendus/6-keypad.ch8", chip8.QuirksSChipModern},
+	}
+
+	for _, sc := range scenarios {
+		t.Run(sc.script, func(t *testing.T) {
+			emu, err := host.NewEmu()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := emu.ReadROM(filepath.Join(romDir, sc.rom)); err != nil {
+				t.Fatal(err)
+			}
+			emu.VM.SetQuirks(sc.quirks)
+
+			s, err := host.LoadInputScript(filepath.Join("../../../testdata/scripts", sc.script))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := emu.RunScript(s, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, shot := range res.Screenshots {
+				golden.AssertPNG(t, shot.Name, shot.PNG)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+// Package testkit runs ROMs headlessly and checks their frames against
+// golden PNG images.
+//
+// It is meant for _test.go files of this and downstream projects:
+//
+//	func TestIBMLogo(t *testing.T) {
+//		g := testkit.Golden{Dir: "testdata/golden"}
+//		g.AssertCase(t, testkit.Case{ROM: "roms/ibm-logo.ch8", Frames: 600})
+//	}
+//
+// Importing the package registers the -update-golden test flag, which
+// rewrites golden images instead of comparing against them. On mismatch the
+// rendered frame and a diff image highlighting the differing pixels are
+// written next to each other in Golden.DiffDir.
+package testkit
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+	"github.com/mxmgorin/ch8go/pkg/host"
+)
+
+// DefaultFrames is the number of frames a Case runs after its script when
+// Frames is zero.
+const DefaultFrames = 600
+
+// Update reports whether golden images should be rewritten.
+var Update = flag.Bool("update-golden", false, "write golden PNG images")
+
+// Case describes one ROM run.
+type Case struct {
+	// Name of the golden image without extension; defaults to the ROM file
+	// name without extension.
+	Name   string
+	ROM    string
+	Quirks *chip8.Quirks // nil keeps the auto-detected quirks
+	Script *host.InputScript
+	Frames int // frames run after the script
+	Seed   int64
+}
+
+func (c *Case) name() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	base := filepath.Base(c.ROM)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// Run loads the ROM of c, executes its script and runs its frames. The
+// random source is seeded with c.Seed so runs are repeatable.
+func Run(t testing.TB, c Case) *host.Emu {
+	t.Helper()
+
+	emu, err := host.NewEmu()
+	if err != nil {
+		t.Fatalf("NewEmu: %v", err)
+	}
+
+	if _, err := emu.ReadROM(c.ROM); err != nil {
+		t.Fatalf("failed to read ROM %s: %v", c.ROM, err)
+	}
+
+	if c.Quirks != nil {
+		emu.VM.SetQuirks(*c.Quirks)
+	}
+	emu.VM.CPU.Seed(c.Seed)
+
+	if _, err := emu.RunScript(c.Script, 0); err != nil {
+		t.Fatalf("script for %s: %v", c.ROM, err)
+	}
+
+	frames := c.Frames
+	if frames == 0 {
+		frames = DefaultFrames
+	}
+	emu.RunFrames(frames)
+
+	return emu
+}
+
+// Taps returns a script that presses and releases each key in turn, holding
+// it for frames frames and then waiting as long again.
+func Taps(frames int, keys ...chip8.Key) *host.InputScript {
+	s := &host.InputScript{}
+	for _, key := range keys {
+		s.Steps = append(s.Steps,
+			host.ScriptStep{Op: host.ScriptPress, At: -1, Key: key, Hold: frames},
+			host.ScriptStep{Op: host.ScriptWait, At: -1, Frames: 2 * frames},
+		)
+	}
+	return s
+}
+
+// Golden compares frames against PNG files in Dir.
+type Golden struct {
+	Dir string
+	// DiffDir receives "<name>_got.png" and "<name>_diff.png" on mismatch;
+	// defaults to the OS temp directory.
+	DiffDir string
+}
+
+// Path returns the golden image path for name.
+func (g Golden) Path(name string) string {
+	return filepath.Join(g.Dir, name+".png")
+}
+
+// AssertCase runs c and compares the final frame with its golden image.
+func (g Golden) AssertCase(t testing.TB, c Case) *host.Emu {
+	t.Helper()
+
+	emu := Run(t, c)
+	g.Assert(t, c.name(), &emu.FrameBuffer)
+
+	return emu
+}
+
+// Assert compares fb with the golden image name.
+func (g Golden) Assert(t testing.TB, name string, fb *host.FrameBuffer) {
+	t.Helper()
+
+	got, err := fb.PNG()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g.AssertPNG(t, name, got)
+}
+
+// AssertPNG compares an encoded PNG, such as a script screenshot, with the
+// golden image name.
+func (g Golden) AssertPNG(t testing.TB, name string, got []byte) {
+	t.Helper()
+
+	path := g.Path(name)
+
+	if *Update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gotImg, err := png.Decode(bytes.NewReader(got))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantImg, err := png.Decode(bytes.NewReader(want))
+	if err != nil {
+		t.Fatalf("golden %s: %v", path, err)
+	}
+
+	diff, n, err := Compare(gotImg, wantImg)
+	if err != nil {
+		t.Fatalf("framebuffer mismatch for %s: %v", name, err)
+	}
+	if n == 0 {
+		return
+	}
+
+	dir := g.DiffDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	gotPath := filepath.Join(dir, name+"_got.png")
+	diffPath := filepath.Join(dir, name+"_diff.png")
+
+	if err := writePNG(gotPath, gotImg); err != nil {
+		t.Log(err)
+	}
+	if err := writePNG(diffPath, diff); err != nil {
+		t.Log(err)
+	}
+
+	t.Fatalf("framebuffer mismatch for %s: %d pixels differ (got %s, diff %s)", name, n, gotPath, diffPath)
+}
+
+var diffColor = color.RGBA{R: 0xFF, A: 0xFF}
+
+// Compare returns the number of pixels that differ between got and want and
+// an image of want in dimmed grayscale with those pixels marked in red.
+// Images of different sizes are reported as an error.
+func Compare(got, want image.Image) (diff *image.RGBA, mismatched int, err error) {
+	gb, wb := got.Bounds(), want.Bounds()
+	if gb.Size() != wb.Size() {
+		return nil, 0, fmt.Errorf("size %dx%d, want %dx%d", gb.Dx(), gb.Dy(), wb.Dx(), wb.Dy())
+	}
+
+	diff = image.NewRGBA(image.Rect(0, 0, wb.Dx(), wb.Dy()))
+
+	for y := range wb.Dy() {
+		for x := range wb.Dx() {
+			g := color.RGBAModel.Convert(got.At(gb.Min.X+x, gb.Min.Y+y))
+			w := color.RGBAModel.Convert(want.At(wb.Min.X+x, wb.Min.Y+y))
+
+			if g != w {
+				mismatched++
+				diff.SetRGBA(x, y, diffColor)
+				continue
+			}
+
+			gray := color.GrayModel.Convert(w).(color.Gray).Y / 3
+			diff.SetRGBA(x, y, color.RGBA{R: gray, G: gray, B: gray, A: 0xFF})
+		}
+	}
+
+	return diff, mismatched, nil
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
@@ -0,0 +1,61 @@
+package testkit
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/mxmgorin/ch8go/pkg/chip8"
+	"github.com/mxmgorin/ch8go/pkg/host"
+)
+
+func TestCompare(t *testing.T) {
+	want := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	got := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	got.SetRGBA(1, 1, color.RGBA{G: 0xFF, A: 0xFF})
+	got.SetRGBA(3, 0, color.RGBA{B: 0xFF, A: 0xFF})
+
+	diff, n, err := Compare(got, want)
+	if err != nil {
+		t.Fatalf("Compare error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("mismatched = %d, want 2", n)
+	}
+	if c := diff.RGBAAt(1, 1); c != diffColor {
+		t.Errorf("diff(1,1) = %v, want %v", c, diffColor)
+	}
+	if c := diff.RGBAAt(0, 0); c == diffColor {
+		t.Error("matching pixel should not be highlighted")
+	}
+
+	if _, _, err := Compare(image.NewRGBA(image.Rect(0, 0, 2, 2)), want); err == nil {
+		t.Error("Compare should reject images of different sizes")
+	}
+}
+
+func TestTaps(t *testing.T) {
+	s := Taps(10, chip8.Key1, chip8.Key2)
+
+	want := []host.ScriptStep{
+		{Op: host.ScriptPress, At: -1, Key: chip8.Key1, Hold: 10},
+		{Op: host.ScriptWait, At: -1, Frames: 20},
+		{Op: host.ScriptPress, At: -1, Key: chip8.Key2, Hold: 10},
+		{Op: host.ScriptWait, At: -1, Frames: 20},
+	}
+	if len(s.Steps) != len(want) {
+		t.Fatalf("steps = %+v, want %+v", s.Steps, want)
+	}
+	for i, w := range want {
+		if s.Steps[i] != w {
+			t.Errorf("Steps[%d] = %+v, want %+v", i, s.Steps[i], w)
+		}
+	}
+}
+
+func TestCaseName(t *testing.T) {
+	c := Case{ROM: "roms/ibm-logo.ch8"}
+	if got := c.name(); got != "ibm-logo" {
+		t.Errorf("name() = %q, want %q", got, "ibm-logo")
+	}
+}
@@ -95,6 +95,7 @@ type FrameBuffer struct {
 	Width      int
 	Height     int
 	BPP        int
+	Rotation   int // clockwise degrees: 0, 90, 180 or 270
 }
 
 func newFrameBuffer(w, h, bpp int) FrameBuffer {
@@ -140,17 +141,7 @@ func (fb *FrameBuffer) SavePNG(path string) error {
 
 func (fb *FrameBuffer) Update(state chip8.FrameState, pal *Palette, display *chip8.Display) {
 	if state.Dirty {
-		pixelsCount := display.Size().Area()
-		planes := display.Planes
-		bpp := fb.BPP
-		fbp := fb.Pixels
-		palette := pal.Pixels
-
-		for i := range pixelsCount {
-			colorIdx := int(planes[0][i]) | int(planes[1][i])<<1 | int(planes[2][i])<<2 | int(planes[3][i])<<3
-			idx := i * bpp
-			copy(fbp[idx:idx+4], palette[colorIdx][:])
-		}
+		fb.draw(pal, display)
 	}
 
 	if state.Beep {
@@ -160,9 +151,65 @@ func (fb *FrameBuffer) Update(state chip8.FrameState, pal *Palette, display *chi
 	}
 }
 
+// SetRotation turns the frame clockwise by deg, swapping Width and Height
+// for 90 and 270, and redraws it from display.
+func (fb *FrameBuffer) SetRotation(deg int, pal *Palette, display *chip8.Display) error {
+	switch deg {
+	case 0, 90, 180, 270:
+	default:
+		return fmt.Errorf("invalid rotation %d (use 0, 90, 180 or 270)", deg)
+	}
+
+	size := display.Size()
+	fb.Rotation = deg
+	fb.Width, fb.Height = size.Width, size.Height
+	if deg == 90 || deg == 270 {
+		fb.Width, fb.Height = size.Height, size.Width
+	}
+
+	fb.draw(pal, display)
+	return nil
+}
+
+func (fb *FrameBuffer) draw(pal *Palette, display *chip8.Display) {
+	size := display.Size()
+	pixelsCount := size.Area()
+	planes := display.Planes
+	bpp := fb.BPP
+	fbp := fb.Pixels
+	palette := pal.Pixels
+
+	for i := range pixelsCount {
+		colorIdx := int(planes[0][i]) | int(planes[1][i])<<1 | int(planes[2][i])<<2 | int(planes[3][i])<<3
+		idx := i * bpp
+		if fb.Rotation != 0 {
+			idx = fb.rotatedIndex(i%size.Width, i/size.Width, size.Width, size.Height) * bpp
+		}
+		copy(fbp[idx:idx+4], palette[colorIdx][:])
+	}
+}
+
+// rotatedIndex maps display pixel (x, y) of a w×h display to its index in
+// the rotated frame.
+func (fb *FrameBuffer) rotatedIndex(x, y, w, h int) int {
+	switch fb.Rotation {
+	case 90:
+		return x*fb.Width + h - 1 - y
+	case 180:
+		return (h-1-y)*fb.Width + w - 1 - x
+	case 270:
+		return (w-1-x)*fb.Width + y
+	}
+	return y*fb.Width + x
+}
+
+// ParseHexColor parses "#rrggbb" or the short form "#rgb".
 func ParseHexColor(s string) (Color, error) {
 	s = strings.TrimPrefix(s, "#")
 
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
 	if len(s) != 6 {
 		return Color{}, fmt.Errorf("invalid hex color: %q", s)
 	}
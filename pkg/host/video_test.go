@@ -15,6 +15,7 @@ func TestParseHexColor(t *testing.T) {
 		{"aabbcc", Color{0xaa, 0xbb, 0xcc, 255}}, // "#" prefix is optional
 		{"#FF0000", Color{255, 0, 0, 255}},
 		{"#000000", Color{0, 0, 0, 255}},
+		{"#fa0", Color{0xff, 0xaa, 0x00, 255}}, // short form, as written by Octo
 	}
 	for _, tt := range tests {
 		got, err := ParseHexColor(tt.in)
@@ -128,3 +129,42 @@ func TestFrameBufferSavePNG(t *testing.T) {
 		t.Error("PNG with BPP != 4 should return an error")
 	}
 }
+
+func TestFrameBufferRotation(t *testing.T) {
+	emu, _ := NewEmu()
+	d := &emu.VM.Display
+	size := d.Size()
+	// Light the top-left pixel.
+	d.Planes[0][0] = 1
+
+	tests := []struct {
+		deg        int
+		w, h, x, y int
+	}{
+		{0, size.Width, size.Height, 0, 0},
+		{90, size.Height, size.Width, size.Height - 1, 0},
+		{180, size.Width, size.Height, size.Width - 1, size.Height - 1},
+		{270, size.Height, size.Width, 0, size.Width - 1},
+	}
+
+	for _, tt := range tests {
+		if err := emu.SetRotation(tt.deg); err != nil {
+			t.Fatal(err)
+		}
+		fb := &emu.FrameBuffer
+		if fb.Width != tt.w || fb.Height != tt.h {
+			t.Errorf("%d°: size %dx%d, want %dx%d", tt.deg, fb.Width, fb.Height, tt.w, tt.h)
+		}
+		idx := (tt.y*fb.Width + tt.x) * fb.BPP
+		if got := Color(fb.Pixels[idx : idx+4]); got != emu.Palette.Pixels[1] {
+			t.Errorf("%d°: pixel (%d,%d) = %v, want the lit color", tt.deg, tt.x, tt.y, got)
+		}
+	}
+
+	if err := emu.SetRotation(45); err == nil {
+		t.Error("SetRotation(45) expected an error")
+	}
+	if got := emu.Rotate(); got != 0 {
+		t.Errorf("Rotate() from 270 = %d, want 0", got)
+	}
+}
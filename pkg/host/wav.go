@@ -0,0 +1,122 @@
+package host
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// AudioRecorder captures the samples produced by chip8.Audio and encodes
+// them as a mono 16-bit PCM WAV file.
+//
+// Samples are stored as generated, before volume or mute is applied, so a
+// recording reflects the ROM rather than the host mixer settings.
+type AudioRecorder struct {
+	SampleRate int
+	samples    []int16
+}
+
+func NewAudioRecorder(sampleRate int) *AudioRecorder {
+	return &AudioRecorder{SampleRate: sampleRate}
+}
+
+func (r *AudioRecorder) Write(samples []float32) {
+	for _, v := range samples {
+		v = min(max(v, -1), 1)
+		r.samples = append(r.samples, int16(v*32767))
+	}
+}
+
+func (r *AudioRecorder) Len() int {
+	return len(r.samples)
+}
+
+func (r *AudioRecorder) Duration() time.Duration {
+	if r.SampleRate <= 0 {
+		return 0
+	}
+	return time.Duration(len(r.samples)) * time.Second / time.Duration(r.SampleRate)
+}
+
+func (r *AudioRecorder) Reset() {
+	r.samples = r.samples[:0]
+}
+
+// WAV encodes the recorded samples as a RIFF/WAVE file.
+func (r *AudioRecorder) WAV() ([]byte, error) {
+	if r.SampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate: %d", r.SampleRate)
+	}
+
+	const (
+		channels      = 1
+		bitsPerSample = 16
+		blockAlign    = channels * bitsPerSample / 8
+	)
+
+	dataSize := len(r.samples) * blockAlign
+	var buf bytes.Buffer
+	buf.Grow(44 + dataSize)
+
+	header := []any{
+		[4]byte{'R', 'I', 'F', 'F'},
+		uint32(36 + dataSize),
+		[4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '},
+		uint32(16), // fmt chunk size
+		uint16(1),  // PCM
+		uint16(channels),
+		uint32(r.SampleRate),
+		uint32(r.SampleRate * blockAlign), // byte rate
+		uint16(blockAlign),
+		uint16(bitsPerSample),
+		[4]byte{'d', 'a', 't', 'a'},
+		uint32(dataSize),
+	}
+
+	for _, v := range header {
+		if err := binary.Write(&buf, binary.LittleEndian, v); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := binary.Write(&buf, binary.LittleEndian, r.samples); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Hash returns the SHA-256 of the encoded WAV file.
+func (r *AudioRecorder) Hash() (string, error) {
+	data, err := r.WAV()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("%x", sum[:]), nil
+}
+
+func (r *AudioRecorder) SaveWAV(path string) error {
+	data, err := r.WAV()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// RecordingPath returns a timestamped file name in the working directory,
+// e.g. "ch8go-20240102-150405.wav".
+func RecordingPath(ext string) string {
+	return "ch8go-" + time.Now().Format("20060102-150405") + ext
+}
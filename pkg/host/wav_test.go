@@ -0,0 +1,109 @@
+package host
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAudioRecorderWAV(t *testing.T) {
+	r := NewAudioRecorder(8000)
+	r.Write([]float32{0, 1, -1, 2}) // out-of-range input is clamped
+
+	if r.Len() != 4 {
+		t.Fatalf("Len() = %d, want 4", r.Len())
+	}
+
+	data, err := r.WAV()
+	if err != nil {
+		t.Fatalf("WAV() error = %v", err)
+	}
+	if len(data) != 44+8 {
+		t.Fatalf("WAV() size = %d, want 52", len(data))
+	}
+	if string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" || string(data[36:40]) != "data" {
+		t.Errorf("WAV() header = %q, want RIFF/WAVE/data", data[:44])
+	}
+	if rate := binary.LittleEndian.Uint32(data[24:28]); rate != 8000 {
+		t.Errorf("sample rate = %d, want 8000", rate)
+	}
+	if bits := binary.LittleEndian.Uint16(data[34:36]); bits != 16 {
+		t.Errorf("bits per sample = %d, want 16", bits)
+	}
+
+	want := []int16{0, 32767, -32767, 32767}
+	for i, w := range want {
+		got := int16(binary.LittleEndian.Uint16(data[44+i*2:]))
+		if got != w {
+			t.Errorf("sample %d = %d, want %d", i, got, w)
+		}
+	}
+
+	if _, err := NewAudioRecorder(0).WAV(); err == nil {
+		t.Error("WAV() with a zero sample rate should return an error")
+	}
+}
+
+func TestAudioRecorderSaveWAV(t *testing.T) {
+	r := NewAudioRecorder(8000)
+	r.Write(make([]float32, 8000))
+
+	if d := r.Duration(); d != time.Second {
+		t.Errorf("Duration() = %v, want 1s", d)
+	}
+
+	path := filepath.Join(t.TempDir(), "out", "audio.wav")
+	if err := r.SaveWAV(path); err != nil {
+		t.Fatalf("SaveWAV error = %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 44+16000 {
+		t.Errorf("expected a 16044-byte WAV at %s: %v", path, err)
+	}
+
+	r.Reset()
+	if r.Len() != 0 {
+		t.Errorf("Len() after Reset = %d, want 0", r.Len())
+	}
+}
+
+func TestEmuAudioRecording(t *testing.T) {
+	emu, _ := NewEmu()
+	if emu.StopAudioRecording() != nil {
+		t.Error("StopAudioRecording() without a recording should return nil")
+	}
+
+	// LD V0, 0A ; LD ST, V0 ; JP 0204
+	rom := []byte{0x60, 0x0A, 0xF0, 0x18, 0x12, 0x04}
+	if _, err := emu.LoadROM(rom, ".ch8"); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := emu.StartAudioRecording()
+	if !emu.AudioRecording() {
+		t.Fatal("AudioRecording() = false after StartAudioRecording")
+	}
+
+	emu.RunFrames(60)
+
+	if got := emu.StopAudioRecording(); got != rec {
+		t.Fatal("StopAudioRecording() should return the active recorder")
+	}
+	if emu.AudioRecording() {
+		t.Error("AudioRecording() = true after StopAudioRecording")
+	}
+
+	// 60 frames of 1/60 s at 44.1 kHz, short of one sample due to truncation.
+	if rec.Len() != DefaultSampleRate-1 {
+		t.Errorf("recorded %d samples, want %d", rec.Len(), DefaultSampleRate-1)
+	}
+
+	var audible bool
+	for _, v := range rec.samples {
+		audible = audible || v != 0
+	}
+	if !audible {
+		t.Error("recording should contain the beep")
+	}
+}
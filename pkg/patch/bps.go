@@ -0,0 +1,137 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+var bpsMagic = []byte("BPS1")
+
+// BPS actions.
+const (
+	bpsSourceRead = iota
+	bpsTargetRead
+	bpsSourceCopy
+	bpsTargetCopy
+)
+
+const (
+	bpsFooter     = 12       // source, target and patch CRC32s
+	maxTargetSize = 16 << 20 // guards the allocation against corrupt headers
+)
+
+// ApplyBPS applies a BPS patch to rom, verifying the patch, the source ROM
+// and the result against the CRC32s in the patch.
+func ApplyBPS(rom, patch []byte) ([]byte, error) {
+	if !bytes.HasPrefix(patch, bpsMagic) {
+		return nil, ErrFormat
+	}
+	if len(patch) < len(bpsMagic)+bpsFooter {
+		return nil, fmt.Errorf("%w: too short", ErrCorrupt)
+	}
+
+	footer := patch[len(patch)-bpsFooter:]
+	if crc32.ChecksumIEEE(patch[:len(patch)-4]) != binary.LittleEndian.Uint32(footer[8:]) {
+		return nil, ErrPatchCRC
+	}
+	if crc32.ChecksumIEEE(rom) != binary.LittleEndian.Uint32(footer[0:]) {
+		return nil, ErrSourceCRC
+	}
+
+	r := reader{data: patch[:len(patch)-bpsFooter], pos: len(bpsMagic)}
+	sourceSize := r.varint()
+	targetSize := r.varint()
+	r.bytes(int(r.varint())) // metadata
+	if r.err != nil || sourceSize != uint64(len(rom)) || targetSize > maxTargetSize {
+		return nil, fmt.Errorf("%w: header", ErrCorrupt)
+	}
+
+	out := make([]byte, 0, targetSize)
+	var sourceRel, targetRel int
+	for r.remaining() > 0 && r.err == nil {
+		action := r.varint()
+		n := int(action>>2) + 1
+		if uint64(len(out)+n) > targetSize {
+			return nil, fmt.Errorf("%w: writes past the target size", ErrCorrupt)
+		}
+
+		switch action & 3 {
+		case bpsSourceRead:
+			if len(out)+n > len(rom) {
+				return nil, fmt.Errorf("%w: source read out of range", ErrCorrupt)
+			}
+			out = append(out, rom[len(out):len(out)+n]...)
+
+		case bpsTargetRead:
+			out = append(out, r.bytes(n)...)
+
+		case bpsSourceCopy:
+			// Compared before adding, since offsets may be near the int limits.
+			off := r.offset()
+			if off < -sourceRel || off > len(rom)-sourceRel {
+				return nil, fmt.Errorf("%w: source copy out of range", ErrCorrupt)
+			}
+			sourceRel += off
+			if n > len(rom)-sourceRel {
+				return nil, fmt.Errorf("%w: source copy out of range", ErrCorrupt)
+			}
+			out = append(out, rom[sourceRel:sourceRel+n]...)
+			sourceRel += n
+
+		case bpsTargetCopy:
+			off := r.offset()
+			if off < -targetRel || off >= len(out)-targetRel {
+				return nil, fmt.Errorf("%w: target copy out of range", ErrCorrupt)
+			}
+			targetRel += off
+			// Byte by byte: the copy may overlap what it writes.
+			for range n {
+				out = append(out, out[targetRel])
+				targetRel++
+			}
+		}
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("%w: action at %d", ErrCorrupt, r.pos)
+	}
+
+	if uint64(len(out)) != targetSize {
+		return nil, fmt.Errorf("%w: target size", ErrCorrupt)
+	}
+	if crc32.ChecksumIEEE(out) != binary.LittleEndian.Uint32(footer[4:]) {
+		return nil, ErrTargetCRC
+	}
+	return out, nil
+}
+
+// varint reads a BPS variable-length number.
+func (r *reader) varint() uint64 {
+	var v uint64
+	shift := uint64(1)
+	for r.err == nil {
+		b := r.byte()
+		v += uint64(b&0x7F) * shift
+		if b&0x80 != 0 {
+			break
+		}
+		if shift > 1<<56 {
+			r.err = ErrCorrupt
+			break
+		}
+		shift <<= 7
+		v += shift
+	}
+	return v
+}
+
+// offset reads a signed relative offset of a copy action.
+func (r *reader) offset() int {
+	v := r.varint()
+	n := int(v >> 1)
+	if v&1 != 0 {
+		return -n
+	}
+	return n
+}
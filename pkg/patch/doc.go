@@ -0,0 +1,4 @@
+// Package patch applies IPS and BPS patches to ROM bytes.
+//
+// The package has no emulator logic and depends only on the standard library.
+package patch
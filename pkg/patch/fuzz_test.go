@@ -0,0 +1,25 @@
+package patch
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+)
+
+func FuzzApply(f *testing.F) {
+	f.Add([]byte{0, 1, 2, 3}, []byte("PATCH\x00\x00\x01\x00\x02\xAA\xBBEOF"))
+	f.Add([]byte{0, 1, 2, 3}, []byte("PATCH\x00\x00\x02\x00\x00\x00\x03\xEEEOF\x00\x00\x02"))
+	f.Add([]byte{0, 1, 2, 3}, []byte{0x84, 0x84, 0x80, 0x8E, 0x83})
+
+	f.Fuzz(func(t *testing.T, rom, data []byte) {
+		Apply(rom, data)
+
+		// Frame data as a BPS patch for rom with valid source and patch
+		// CRC32s, so that the actions are reached.
+		p := append(append([]byte{}, bpsMagic...), data...)
+		p = binary.LittleEndian.AppendUint32(p, crc32.ChecksumIEEE(rom))
+		p = binary.LittleEndian.AppendUint32(p, 0)
+		p = binary.LittleEndian.AppendUint32(p, crc32.ChecksumIEEE(p))
+		Apply(rom, p)
+	})
+}
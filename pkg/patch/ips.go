@@ -0,0 +1,97 @@
+package patch
+
+import (
+	"bytes"
+	"fmt"
+)
+
+var (
+	ipsMagic = []byte("PATCH")
+	ipsEOF   = []byte("EOF")
+)
+
+// ApplyIPS applies an IPS patch to rom. It supports run-length records
+// and the truncation extension, a 3-byte size after the EOF marker. IPS
+// has no checksums, so a patch for another ROM applies silently.
+func ApplyIPS(rom, patch []byte) ([]byte, error) {
+	if !bytes.HasPrefix(patch, ipsMagic) {
+		return nil, ErrFormat
+	}
+	out := bytes.Clone(rom)
+
+	r := reader{data: patch, pos: len(ipsMagic)}
+	for {
+		if bytes.HasPrefix(patch[r.pos:], ipsEOF) {
+			r.pos += len(ipsEOF)
+			break
+		}
+
+		offset := int(r.uint(3))
+		size := int(r.uint(2))
+		var data []byte
+		if size == 0 { // run-length record
+			size = int(r.uint(2))
+			data = bytes.Repeat([]byte{r.byte()}, size)
+		} else {
+			data = r.bytes(size)
+		}
+		if r.err != nil {
+			return nil, fmt.Errorf("%w: record at %d", ErrCorrupt, r.pos)
+		}
+
+		if end := offset + size; end > len(out) {
+			out = append(out, make([]byte, end-len(out))...)
+		}
+		copy(out[offset:], data)
+	}
+
+	switch r.remaining() {
+	case 0:
+	case 3:
+		size := int(r.uint(3))
+		if size < len(out) {
+			out = out[:size]
+		}
+	default:
+		return nil, fmt.Errorf("%w: data after EOF", ErrCorrupt)
+	}
+	return out, nil
+}
+
+// reader reads patch fields, recording the first out-of-range read.
+type reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *reader) remaining() int {
+	return len(r.data) - r.pos
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil || n < 0 || n > r.remaining() {
+		r.err = ErrCorrupt
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *reader) byte() byte {
+	b := r.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+// uint reads an n-byte big-endian number.
+func (r *reader) uint(n int) uint64 {
+	var v uint64
+	for _, b := range r.bytes(n) {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
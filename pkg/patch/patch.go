@@ -0,0 +1,31 @@
+package patch
+
+import (
+	"bytes"
+	"errors"
+)
+
+// Errors returned for patches that do not apply.
+var (
+	ErrFormat    = errors.New("unknown patch format")
+	ErrCorrupt   = errors.New("corrupt patch")
+	ErrSourceCRC = errors.New("patch is for a different ROM (source CRC mismatch)")
+	ErrTargetCRC = errors.New("patched ROM is wrong (target CRC mismatch)")
+	ErrPatchCRC  = errors.New("patch is damaged (patch CRC mismatch)")
+)
+
+// Exts are the file extensions of the supported formats.
+var Exts = []string{".ips", ".bps"}
+
+// Apply applies patch to rom, detecting the format from its header. The
+// rom slice is not modified.
+func Apply(rom, patch []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(patch, ipsMagic):
+		return ApplyIPS(rom, patch)
+	case bytes.HasPrefix(patch, bpsMagic):
+		return ApplyBPS(rom, patch)
+	default:
+		return nil, ErrFormat
+	}
+}
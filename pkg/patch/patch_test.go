@@ -0,0 +1,151 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"math"
+	"testing"
+)
+
+func TestApplyIPS(t *testing.T) {
+	rom := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05}
+
+	tests := []struct {
+		name  string
+		patch []byte
+		want  []byte
+	}{
+		{"record", ips(0x000001, []byte{0xAA, 0xBB}), []byte{0x00, 0xAA, 0xBB, 0x03, 0x04, 0x05}},
+		{"grows", ips(0x000005, []byte{0xCC, 0xDD}), []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0xCC, 0xDD}},
+		{"rle", []byte("PATCH\x00\x00\x02\x00\x00\x00\x03\xEEEOF"), []byte{0x00, 0x01, 0xEE, 0xEE, 0xEE, 0x05}},
+		{"truncate", []byte("PATCHEOF\x00\x00\x04"), []byte{0x00, 0x01, 0x02, 0x03}},
+	}
+	for _, tt := range tests {
+		got, err := Apply(rom, tt.patch)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("%s: got %x, want %x", tt.name, got, tt.want)
+		}
+	}
+
+	if rom[1] != 0x01 {
+		t.Error("Apply modified the source ROM")
+	}
+
+	for _, patch := range [][]byte{
+		[]byte("PATCH\x00\x00\x01\x00\x04\xAA"), // short record
+		[]byte("PATCH\x00\x00\x01\x00\x01\xAA"), // no EOF
+		[]byte("PATCHEOF\x01"),                  // trailing data
+	} {
+		if _, err := ApplyIPS(rom, patch); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("ApplyIPS(%q) error = %v, want ErrCorrupt", patch, err)
+		}
+	}
+}
+
+func TestApplyBPS(t *testing.T) {
+	source := []byte("CHIP-8 GAME v1.0")
+	target := []byte("CHIP-8 GAME v1.1 FIXED!!!!")
+
+	// SourceRead "CHIP-8 GAME v1.", TargetRead "1 FIXED!", then TargetCopy
+	// "!!!" from the last "!", overlapping what it writes.
+	var actions []byte
+	actions = append(actions, bpsAction(bpsSourceRead, 15)...)
+	actions = append(actions, bpsAction(bpsTargetRead, 8)...)
+	actions = append(actions, "1 FIXED!"...)
+	actions = append(actions, bpsAction(bpsTargetCopy, 3)...)
+	actions = append(actions, bpsNumber(22<<1)...) // targetRel 0 → 22
+	patch := bps(source, target, actions)
+
+	got, err := Apply(source, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, target) {
+		t.Errorf("got %q, want %q", got, target)
+	}
+
+	// SourceCopy backwards: "v1.0" then "CHIP".
+	target = []byte("v1.0CHIP")
+	actions = append(bpsAction(bpsSourceCopy, 4), bpsNumber(12<<1)...)
+	actions = append(actions, bpsAction(bpsSourceCopy, 4)...)
+	actions = append(actions, bpsNumber(16<<1|1)...) // sourceRel 16 → 0
+	if got, err := ApplyBPS(source, bps(source, target, actions)); err != nil || !bytes.Equal(got, target) {
+		t.Errorf("source copy: got %q, %v, want %q", got, err, target)
+	}
+
+	if _, err := ApplyBPS([]byte("another ROM"), patch); !errors.Is(err, ErrSourceCRC) {
+		t.Errorf("wrong source: error = %v, want ErrSourceCRC", err)
+	}
+
+	damaged := bytes.Clone(patch)
+	damaged[len(bpsMagic)+4] ^= 0xFF
+	if _, err := ApplyBPS(source, damaged); !errors.Is(err, ErrPatchCRC) {
+		t.Errorf("damaged patch: error = %v, want ErrPatchCRC", err)
+	}
+
+	wrongTarget := bps(source, []byte("something else"), bpsAction(bpsSourceRead, 14))
+	if _, err := ApplyBPS(source, wrongTarget); !errors.Is(err, ErrTargetCRC) {
+		t.Errorf("wrong target: error = %v, want ErrTargetCRC", err)
+	}
+}
+
+func TestApplyBPSOffsetOverflow(t *testing.T) {
+	rom := []byte{1, 2, 3, 4}
+	for _, action := range []int{bpsSourceCopy, bpsTargetCopy} {
+		actions := bpsAction(bpsSourceRead, 1)
+		actions = append(actions, bpsAction(action, 4)...)
+		actions = append(actions, bpsNumber(uint64(math.MaxInt64-2)<<1)...)
+		if _, err := ApplyBPS(rom, bps(rom, []byte{1, 1, 2, 3, 4}, actions)); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("action %d: error = %v, want ErrCorrupt", action, err)
+		}
+	}
+}
+
+func TestApplyUnknownFormat(t *testing.T) {
+	if _, err := Apply([]byte{1}, []byte("UPS1")); !errors.Is(err, ErrFormat) {
+		t.Errorf("error = %v, want ErrFormat", err)
+	}
+}
+
+// ips returns a patch with one record.
+func ips(offset int, data []byte) []byte {
+	p := append([]byte("PATCH"), byte(offset>>16), byte(offset>>8), byte(offset))
+	p = append(p, byte(len(data)>>8), byte(len(data)))
+	p = append(p, data...)
+	return append(p, "EOF"...)
+}
+
+// bps returns a patch from source to target with the given actions.
+func bps(source, target, actions []byte) []byte {
+	p := append([]byte{}, bpsMagic...)
+	p = append(p, bpsNumber(uint64(len(source)))...)
+	p = append(p, bpsNumber(uint64(len(target)))...)
+	p = append(p, bpsNumber(0)...) // no metadata
+	p = append(p, actions...)
+	p = binary.LittleEndian.AppendUint32(p, crc32.ChecksumIEEE(source))
+	p = binary.LittleEndian.AppendUint32(p, crc32.ChecksumIEEE(target))
+	return binary.LittleEndian.AppendUint32(p, crc32.ChecksumIEEE(p))
+}
+
+func bpsAction(action, n int) []byte {
+	return bpsNumber(uint64(n-1)<<2 | uint64(action))
+}
+
+func bpsNumber(n uint64) []byte {
+	var b []byte
+	for {
+		x := byte(n & 0x7F)
+		n >>= 7
+		if n == 0 {
+			return append(b, 0x80|x)
+		}
+		b = append(b, x)
+		n--
+	}
+}